@@ -0,0 +1,505 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/compose-spec/compose-go/v2/consts"
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+var (
+	// archiveMaxSize is the maximum size of files extracted from an archive
+	archiveMaxSize int64 = 1 << 30
+	// archiveMaxEntries is the maximum number of entries in an archive
+	archiveMaxEntries = 10000
+)
+
+// ProjectFromArchive loads a compose project from a tar, tar.gz or zip archive.
+//
+// The archive is extracted into a temporary directory which becomes the project
+// working directory. Compose files are discovered at the archive root using
+// DefaultFileNames and DefaultOverrideFileNames, unless ConfigPaths are set by
+// opts, in which case they are resolved relative to the archive root. Otherwise,
+// if the archive only contains a single top-level directory, this one is used as
+// archive root. The `.env` file (or EnvFiles) is loaded relative to the archive root.
+//
+// Any file reference (include, extends, env_file, secret or config file, bind
+// mount, build context) which escapes the archive root is rejected. Relative
+// references are resolved from the directory of the compose file declaring them.
+// Archives with more than 10000 entries, or more than 1GiB of content, are rejected.
+//
+// The returned cleanup func removes the temporary directory, and must be called by
+// caller once project files are not used anymore.
+func ProjectFromArchive(r io.Reader, opts ...ProjectOptionsFn) (*types.Project, func() error, error) {
+	tmp, err := os.MkdirTemp("", "compose-archive-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() error {
+		return os.RemoveAll(tmp)
+	}
+	project, err := projectFromArchive(r, tmp, opts...)
+	if err != nil {
+		_ = cleanup()
+		return nil, nil, err
+	}
+	return project, cleanup, nil
+}
+
+func projectFromArchive(r io.Reader, tmp string, opts ...ProjectOptionsFn) (*types.Project, error) {
+	dir := filepath.Join(tmp, "project")
+	if err := extractArchive(r, tmp, dir); err != nil {
+		return nil, err
+	}
+	options, err := NewProjectOptions(nil, append([]ProjectOptionsFn{WithWorkingDirectory(dir)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	root := dir
+	if len(options.ConfigPaths) == 0 {
+		root, err = archiveRoot(dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	options.WorkingDir = root
+
+	var configPaths []string
+	for _, p := range options.ConfigPaths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(root, p)
+		}
+		if !isWithin(root, p) {
+			return nil, errors.Wrapf(errdefs.ErrInvalid, "%s is outside archive root", p)
+		}
+		configPaths = append(configPaths, p)
+	}
+	if len(configPaths) == 0 {
+		candidates := findFiles(DefaultFileNames, root)
+		if len(candidates) == 0 {
+			return nil, errors.Wrap(errdefs.ErrNotFound, "no configuration file found in archive")
+		}
+		configPaths = append(configPaths, candidates[0])
+		overrides := findFiles(DefaultOverrideFileNames, root)
+		if len(overrides) > 0 {
+			configPaths = append(configPaths, overrides[0])
+		}
+	}
+	options.ConfigPaths = configPaths
+
+	for i, f := range options.EnvFiles {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(root, f)
+		}
+		if !isWithin(root, f) {
+			return nil, errors.Wrapf(errdefs.ErrInvalid, "%s is outside archive root", f)
+		}
+		options.EnvFiles[i] = f
+	}
+	if err := WithDotEnv(options); err != nil {
+		return nil, err
+	}
+
+	// environment is resolved once we checked env_file do not escape archive root
+	var skipResolveEnvironment bool
+	options.loadOptions = append(options.loadOptions, func(o *loader.Options) {
+		skipResolveEnvironment = o.SkipResolveEnvironment
+		o.SkipResolveEnvironment = true
+		o.ResourceLoaders = append([]loader.ResourceLoader{archiveResourceLoader{root: root}}, o.ResourceLoaders...)
+	})
+
+	project, err := ProjectFromOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkArchivePaths(project, root); err != nil {
+		return nil, err
+	}
+	if !skipResolveEnvironment {
+		if err := project.ResolveServicesEnvironment(options.discardEnvFiles); err != nil {
+			return nil, err
+		}
+	}
+	return project, nil
+}
+
+// archiveResourceLoader resolves relative references escaping the declaring compose file directory, and rejects
+// resources referenced by path outside archive root
+type archiveResourceLoader struct {
+	root string
+}
+
+func (a archiveResourceLoader) Accept(p string) bool {
+	if filepath.IsAbs(p) {
+		return !isWithin(a.root, p)
+	}
+	p = filepath.Clean(p)
+	return p == ".." || strings.HasPrefix(p, ".."+string(filepath.Separator))
+}
+
+func (a archiveResourceLoader) Load(ctx context.Context, p string) (string, error) {
+	if !filepath.IsAbs(p) {
+		dir := a.root
+		if file, ok := ctx.Value(consts.ComposeFileKey{}).(string); ok && filepath.IsAbs(file) {
+			dir = filepath.Dir(file)
+		}
+		if local := filepath.Join(dir, p); isWithin(a.root, local) {
+			return local, nil
+		}
+	}
+	return "", errors.Wrapf(errdefs.ErrInvalid, "%s is outside archive root", p)
+}
+
+// checkArchivePaths checks local files used by project are all within archive root
+func checkArchivePaths(project *types.Project, root string) error {
+	check := func(attr string, p string) error {
+		if p == "" {
+			return nil
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(project.WorkingDir, p)
+		}
+		if isWithin(root, p) {
+			return nil
+		}
+		return errors.Wrapf(errdefs.ErrInvalid, "%s %s is outside archive root", attr, p)
+	}
+	// build contexts can also be a URL, so only absolute paths are local ones
+	checkContext := func(attr string, p string) error {
+		if !filepath.IsAbs(p) {
+			return nil
+		}
+		return check(attr, p)
+	}
+	for name, s := range project.AllServices() {
+		for _, f := range s.EnvFile {
+			if err := check(fmt.Sprintf("services.%s.env_file", name), f); err != nil {
+				return err
+			}
+		}
+		if s.Build != nil {
+			if err := checkContext(fmt.Sprintf("services.%s.build.context", name), s.Build.Context); err != nil {
+				return err
+			}
+			for key, c := range s.Build.AdditionalContexts {
+				if err := checkContext(fmt.Sprintf("services.%s.build.additional_contexts.%s", name, key), c); err != nil {
+					return err
+				}
+			}
+		}
+		for i, v := range s.Volumes {
+			if v.Type != types.VolumeTypeBind {
+				continue
+			}
+			if err := check(fmt.Sprintf("services.%s.volumes[%d].source", name, i), v.Source); err != nil {
+				return err
+			}
+		}
+	}
+	for name, v := range project.Volumes {
+		if v.Driver == "local" && v.DriverOpts["o"] == "bind" {
+			if err := check(fmt.Sprintf("volumes.%s.driver_opts.device", name), v.DriverOpts["device"]); err != nil {
+				return err
+			}
+		}
+	}
+	for name, s := range project.Secrets {
+		if err := check(fmt.Sprintf("secrets.%s.file", name), s.File); err != nil {
+			return err
+		}
+	}
+	for name, c := range project.Configs {
+		if err := check(fmt.Sprintf("configs.%s.file", name), c.File); err != nil {
+			return err
+		}
+	}
+	for _, includes := range project.IncludeReferences {
+		for _, include := range includes {
+			if err := check("include.project_directory", include.ProjectDirectory); err != nil {
+				return err
+			}
+			for _, f := range include.EnvFile {
+				if err := check("include.env_file", f); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// archiveRoot selects the single top-level directory of an archive as root
+// when no compose file is present at archive top-level.
+func archiveRoot(dir string) (string, error) {
+	if len(findFiles(DefaultFileNames, dir)) > 0 {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return dir, nil
+}
+
+// extractArchive detects archive format and extracts content into dir. As zip format requires random access,
+// zip archives are first copied into tmp.
+func extractArchive(r io.Reader, tmp string, dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		f, err := os.CreateTemp(tmp, "archive-*.zip")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name()) //nolint:errcheck
+		defer f.Close()           //nolint:errcheck
+		size, err := io.Copy(f, io.LimitReader(br, archiveMaxSize+1))
+		if err != nil {
+			return err
+		}
+		if size > archiveMaxSize {
+			return errors.Wrapf(errdefs.ErrInvalid, "archive exceeds %d bytes", archiveMaxSize)
+		}
+		return extractZip(f, size, dir)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close() //nolint:errcheck
+		return extractTar(gz, dir)
+	default:
+		return extractTar(br, dir)
+	}
+}
+
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	var limits archiveLimits
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return checkArchiveSymlinks(dir)
+		}
+		if err != nil {
+			return errors.Wrap(err, "invalid archive")
+		}
+		if err := limits.entry(); err != nil {
+			return err
+		}
+		target, err := archiveEntryPath(dir, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(target, tr, header.FileInfo().Mode(), &limits); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			link := header.Linkname
+			if !filepath.IsAbs(link) {
+				link = filepath.Join(filepath.Dir(target), link)
+			}
+			if !isWithin(dir, link) {
+				return errors.Wrapf(errdefs.ErrInvalid, "symlink %s is outside archive root", header.Name)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		default:
+			return errors.Wrapf(errdefs.ErrUnsupported, "archive entry %s has unsupported type %c", header.Name, header.Typeflag)
+		}
+	}
+}
+
+// checkArchiveSymlinks checks symlinks extracted into dir resolve to a path inside dir. As symlinks can target
+// other symlinks, this can only be checked once all of them have been extracted.
+func checkArchiveSymlinks(dir string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.Type()&fs.ModeSymlink == 0 {
+			return err
+		}
+		resolved, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			rel, _ := filepath.Rel(dir, p)
+			return errors.Wrapf(errdefs.ErrInvalid, "symlink %s can't be resolved", rel)
+		}
+		if !isWithin(root, resolved) {
+			rel, _ := filepath.Rel(dir, p)
+			return errors.Wrapf(errdefs.ErrInvalid, "symlink %s is outside archive root", rel)
+		}
+		return nil
+	})
+}
+
+func extractZip(r io.ReaderAt, size int64, dir string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return errors.Wrap(err, "invalid archive")
+	}
+	var limits archiveLimits
+	for _, f := range zr.File {
+		if err := limits.entry(); err != nil {
+			return err
+		}
+		target, err := archiveEntryPath(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = writeArchiveFile(target, rc, mode, &limits)
+			_ = rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.Wrapf(errdefs.ErrUnsupported, "archive entry %s has unsupported type %s", f.Name, mode.Type())
+		}
+	}
+	return nil
+}
+
+// archiveEntryPath computes the local path for an archive entry, rejecting those escaping dir
+func archiveEntryPath(dir string, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", errors.Wrapf(errdefs.ErrInvalid, "archive entry %s is outside archive root", name)
+	}
+	target := filepath.Join(dir, name)
+	if !isWithin(dir, target) {
+		return "", errors.Wrapf(errdefs.ErrInvalid, "archive entry %s is outside archive root", name)
+	}
+	// never follow a symlink extracted from archive, which could point outside archive root
+	rel, _ := filepath.Rel(dir, target)
+	p := dir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		p = filepath.Join(p, part)
+		fi, err := os.Lstat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return "", errors.Wrapf(errdefs.ErrInvalid, "archive entry %s is written through a symlink", name)
+		}
+	}
+	return target, nil
+}
+
+func writeArchiveFile(target string, r io.Reader, mode os.FileMode, limits *archiveLimits) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	// entry size declared by archive can't be trusted, so copy is limited to the remaining allowed size
+	n, err := io.Copy(f, io.LimitReader(r, archiveMaxSize-limits.size+1))
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := limits.add(n); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// archiveLimits counts entries and bytes extracted from an archive, so that extraction stops once limits are
+// exceeded
+type archiveLimits struct {
+	entries int
+	size    int64
+}
+
+func (l *archiveLimits) entry() error {
+	l.entries++
+	if l.entries > archiveMaxEntries {
+		return errors.Wrapf(errdefs.ErrInvalid, "archive has more than %d entries", archiveMaxEntries)
+	}
+	return nil
+}
+
+func (l *archiveLimits) add(n int64) error {
+	l.size += n
+	if l.size > archiveMaxSize {
+		return errors.Wrapf(errdefs.ErrInvalid, "archive content exceeds %d bytes", archiveMaxSize)
+	}
+	return nil
+}
+
+// isWithin returns true if path is root or a path inside root
+func isWithin(root string, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
@@ -0,0 +1,338 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func tarball(t *testing.T, files map[string]string) []byte {
+	buf := bytes.Buffer{}
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		assert.NilError(t, err)
+		_, err = tw.Write([]byte(content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+	assert.NilError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zipball(t *testing.T, files map[string]string) []byte {
+	buf := bytes.Buffer{}
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NilError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestProjectFromArchive(t *testing.T) {
+	files := map[string]string{
+		"myapp/compose.yaml": `
+services:
+  web:
+    image: nginx:${TAG}
+    env_file: web.env
+`,
+		"myapp/compose.override.yaml": `
+services:
+  web:
+    environment:
+      - OVERRIDE=true
+`,
+		"myapp/.env":    "TAG=1.25",
+		"myapp/web.env": "FOO=BAR",
+	}
+
+	for format, content := range map[string][]byte{
+		"tgz": tarball(t, files),
+		"zip": zipball(t, files),
+	} {
+		t.Run(format, func(t *testing.T) {
+			p, cleanup, err := ProjectFromArchive(bytes.NewReader(content))
+			assert.NilError(t, err)
+			defer cleanup() //nolint:errcheck
+			assert.Equal(t, p.Name, "myapp")
+			assert.Equal(t, len(p.ComposeFiles), 2)
+			web := p.Services["web"]
+			assert.Equal(t, web.Image, "nginx:1.25")
+			assert.Equal(t, *web.Environment["FOO"], "BAR")
+			assert.Equal(t, *web.Environment["OVERRIDE"], "true")
+			assert.Equal(t, web.EnvFile[0], filepath.Join(p.WorkingDir, "web.env"))
+		})
+	}
+}
+
+func TestProjectFromArchiveConfigPaths(t *testing.T) {
+	content := tarball(t, map[string]string{
+		"deploy/prod.yaml": `
+name: prod
+services:
+  web:
+    image: nginx
+`,
+	})
+	p, cleanup, err := ProjectFromArchive(bytes.NewReader(content), func(o *ProjectOptions) error {
+		o.ConfigPaths = []string{"deploy/prod.yaml"}
+		return nil
+	})
+	assert.NilError(t, err)
+	defer cleanup() //nolint:errcheck
+	assert.Equal(t, p.Name, "prod")
+}
+
+func TestProjectFromArchiveEscape(t *testing.T) {
+	t.Run("archive entry", func(t *testing.T) {
+		content := tarball(t, map[string]string{
+			"compose.yaml":     "services: {}",
+			"../../etc/passwd": "pwned",
+		})
+		_, _, err := ProjectFromArchive(bytes.NewReader(content))
+		assert.ErrorContains(t, err, "outside archive root")
+	})
+
+	t.Run("env_file", func(t *testing.T) {
+		content := tarball(t, map[string]string{
+			"compose.yaml": `
+services:
+  web:
+    image: nginx
+    env_file: /etc/hostname
+`,
+		})
+		_, _, err := ProjectFromArchive(bytes.NewReader(content))
+		assert.ErrorContains(t, err, "services.web.env_file /etc/hostname is outside archive root")
+	})
+
+	t.Run("include", func(t *testing.T) {
+		content := tarball(t, map[string]string{
+			"compose.yaml": `
+include:
+  - ../other/compose.yaml
+services:
+  web:
+    image: nginx
+`,
+		})
+		_, _, err := ProjectFromArchive(bytes.NewReader(content))
+		assert.ErrorContains(t, err, "../other/compose.yaml is outside archive root")
+	})
+
+	t.Run("secret", func(t *testing.T) {
+		content := tarball(t, map[string]string{
+			"compose.yaml": `
+services:
+  web:
+    image: nginx
+secrets:
+  key:
+    file: ../key.pem
+`,
+		})
+		_, _, err := ProjectFromArchive(bytes.NewReader(content))
+		assert.ErrorContains(t, err, "is outside archive root")
+	})
+}
+
+func TestProjectFromArchiveEscapeResolved(t *testing.T) {
+	tests := map[string]struct {
+		compose string
+		err     string
+	}{
+		"bind mount": {
+			compose: `
+services:
+  web:
+    image: nginx
+    volumes:
+      - /etc:/data
+`,
+			err: "services.web.volumes[0].source /etc is outside archive root",
+		},
+		"additional context": {
+			compose: `
+services:
+  web:
+    build:
+      context: .
+      additional_contexts:
+        parent: ..
+`,
+			err: "services.web.build.additional_contexts.parent",
+		},
+		"include project directory": {
+			compose: `
+include:
+  - path: common/compose.yaml
+    project_directory: ..
+`,
+			err: "include.project_directory",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			content := tarball(t, map[string]string{
+				"compose.yaml":        tt.compose,
+				"common/compose.yaml": "services:\n  db:\n    image: postgres\n",
+			})
+			_, _, err := ProjectFromArchive(bytes.NewReader(content))
+			assert.ErrorContains(t, err, tt.err)
+			assert.ErrorContains(t, err, "is outside archive root")
+		})
+	}
+}
+
+func TestProjectFromArchiveNestedReferences(t *testing.T) {
+	content := tarball(t, map[string]string{
+		"compose.yaml": `
+include:
+  - app/compose.yaml
+`,
+		"app/compose.yaml": `
+include:
+  - ../common/compose.yaml
+services:
+  app:
+    extends:
+      file: ../common/base.yaml
+      service: base
+`,
+		"common/compose.yaml": "services:\n  db:\n    image: postgres\n",
+		"common/base.yaml":    "services:\n  base:\n    image: alpine\n",
+	})
+	project, cleanup, err := ProjectFromArchive(bytes.NewReader(content))
+	assert.NilError(t, err)
+	defer cleanup() //nolint:errcheck
+	assert.DeepEqual(t, project.ServiceNames(), []string{"app", "db"})
+	assert.Equal(t, project.Services["app"].Image, "alpine")
+}
+
+func TestProjectFromArchiveLimits(t *testing.T) {
+	files := map[string]string{
+		"compose.yaml": "services:\n  web:\n    image: nginx\n",
+		"data.txt":     "0123456789",
+	}
+	defer func(entries int, size int64) {
+		archiveMaxEntries, archiveMaxSize = entries, size
+	}(archiveMaxEntries, archiveMaxSize)
+
+	archiveMaxEntries = 1
+	_, _, err := ProjectFromArchive(bytes.NewReader(tarball(t, files)))
+	assert.ErrorContains(t, err, "archive has more than 1 entries")
+
+	archiveMaxEntries, archiveMaxSize = 10, 32
+	_, _, err = ProjectFromArchive(bytes.NewReader(tarball(t, files)))
+	assert.ErrorContains(t, err, "archive content exceeds 32 bytes")
+}
+
+// entry is a tar entry, used to control entries order and type
+type entry struct {
+	name     string
+	linkname string
+	content  string
+}
+
+func tarballEntries(t *testing.T, entries ...entry) []byte {
+	buf := bytes.Buffer{}
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		header := &tar.Header{
+			Name:     e.name,
+			Mode:     0o644,
+			Size:     int64(len(e.content)),
+			Typeflag: tar.TypeReg,
+		}
+		if e.linkname != "" {
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = e.linkname
+			header.Size = 0
+		}
+		assert.NilError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(e.content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func TestProjectFromArchiveSymlinks(t *testing.T) {
+	t.Run("symlink within root", func(t *testing.T) {
+		content := tarballEntries(t,
+			entry{name: "compose.yaml", content: "services:\n  web:\n    image: nginx\n    env_file: web.env\n"},
+			entry{name: "env/web.env", content: "FOO=BAR"},
+			entry{name: "web.env", linkname: "env/web.env"},
+		)
+		p, cleanup, err := ProjectFromArchive(bytes.NewReader(content))
+		assert.NilError(t, err)
+		defer cleanup() //nolint:errcheck
+		assert.Equal(t, *p.Services["web"].Environment["FOO"], "BAR")
+	})
+
+	t.Run("entry written through symlinks", func(t *testing.T) {
+		content := tarballEntries(t,
+			entry{name: "compose.yaml", content: "services: {}"},
+			entry{name: "c", linkname: "."},
+			entry{name: "c/d", linkname: ".."},
+			entry{name: "d/e", linkname: ".."},
+			entry{name: "d/e/zzpwned", content: "pwned"},
+		)
+		_, _, err := ProjectFromArchive(bytes.NewReader(content))
+		assert.ErrorContains(t, err, "is written through a symlink")
+	})
+
+	t.Run("chained symlinks", func(t *testing.T) {
+		content := tarballEntries(t,
+			entry{name: "compose.yaml", content: "services: {}"},
+			entry{name: "c", linkname: "."},
+			entry{name: "e", linkname: "c/.."},
+		)
+		_, _, err := ProjectFromArchive(bytes.NewReader(content))
+		assert.ErrorContains(t, err, "symlink e is outside archive root")
+	})
+}
+
+func TestProjectFromArchiveCleanup(t *testing.T) {
+	content := tarball(t, map[string]string{
+		"compose.yaml": "services:\n  web:\n    image: nginx\n",
+	})
+	p, cleanup, err := ProjectFromArchive(bytes.NewReader(content))
+	assert.NilError(t, err)
+	assert.NilError(t, cleanup())
+	_, err = os.Stat(filepath.Dir(p.WorkingDir))
+	assert.Check(t, os.IsNotExist(err))
+}
@@ -0,0 +1,81 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProjectExportRoundTrip(t *testing.T) {
+	wd := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(wd, "compose.yaml"), []byte(`
+name: roundtrip
+services:
+  web:
+    image: nginx
+    env_file: web.env
+    secrets: [key]
+    extends:
+      file: base.yaml
+      service: base
+include:
+  - db/compose.yaml
+secrets:
+  key:
+    file: ./key.pem
+`), 0o600))
+	assert.NilError(t, os.WriteFile(filepath.Join(wd, "web.env"), []byte("FOO=BAR"), 0o600))
+	assert.NilError(t, os.WriteFile(filepath.Join(wd, "key.pem"), []byte("secret"), 0o600))
+	assert.NilError(t, os.WriteFile(filepath.Join(wd, "base.yaml"), []byte(`
+services:
+  base:
+    labels:
+      base: "true"
+`), 0o600))
+	assert.NilError(t, os.MkdirAll(filepath.Join(wd, "db"), 0o700))
+	assert.NilError(t, os.WriteFile(filepath.Join(wd, "db", "compose.yaml"), []byte(`
+services:
+  db:
+    image: postgres
+`), 0o600))
+
+	opts, err := NewProjectOptions([]string{filepath.Join(wd, "compose.yaml")})
+	assert.NilError(t, err)
+	p, err := ProjectFromOptions(opts)
+	assert.NilError(t, err)
+
+	buf := bytes.Buffer{}
+	assert.NilError(t, p.Export(&buf))
+
+	imported, cleanup, err := ProjectFromArchive(&buf)
+	assert.NilError(t, err)
+	defer cleanup() //nolint:errcheck
+	assert.Equal(t, imported.Name, "roundtrip")
+	assert.Equal(t, *imported.Services["web"].Environment["FOO"], "BAR")
+	assert.Equal(t, imported.Secrets["key"].File, filepath.Join(imported.WorkingDir, "key.pem"))
+	assert.Equal(t, imported.Services["web"].Labels["base"], "true")
+	assert.Equal(t, imported.Services["db"].Image, "postgres")
+	for _, f := range []string{"base.yaml", "db/compose.yaml"} {
+		_, err = os.Stat(filepath.Join(imported.WorkingDir, f))
+		assert.NilError(t, err)
+	}
+}
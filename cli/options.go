@@ -18,15 +18,16 @@ package cli
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/compose-spec/compose-go/v2/consts"
+	"github.com/compose-spec/compose-go/v2/diagnostics"
 	"github.com/compose-spec/compose-go/v2/dotenv"
 	"github.com/compose-spec/compose-go/v2/errdefs"
 	"github.com/compose-spec/compose-go/v2/loader"
@@ -78,7 +79,17 @@ type ProjectOptions struct {
 	// exist or an error will be returned during load.
 	EnvFiles []string
 
+	// Diagnostics receives warnings detected while selecting and loading compose files. Defaults to
+	// diagnostics.Logrus. As ProjectOptionsFn are applied in order, WithDiagnostics must be set before
+	// WithDefaultConfigPath to receive its warnings.
+	Diagnostics diagnostics.Sink
+
 	loadOptions []func(*loader.Options)
+
+	// discardEnvFiles is set when `env_file` section is discarded after resolution to `environment`
+	discardEnvFiles bool
+	// dotEnvFiles are the `.env` files loaded by WithDotEnv
+	dotEnvFiles []string
 }
 
 type ProjectOptionsFn func(*ProjectOptions) error
@@ -160,16 +171,22 @@ func WithDefaultConfigPath(o *ProjectOptions) error {
 		if len(candidates) > 0 {
 			winner := candidates[0]
 			if len(candidates) > 1 {
-				logrus.Warnf("Found multiple config files with supported names: %s", strings.Join(candidates, ", "))
-				logrus.Warnf("Using %s", winner)
+				o.sink().Report(diagnostics.Diagnostic{
+					Code:    diagnostics.MultipleConfigFiles,
+					Message: fmt.Sprintf("Found multiple config files with supported names: %s. Using %s", strings.Join(candidates, ", "), winner),
+					File:    winner,
+				})
 			}
 			o.ConfigPaths = append(o.ConfigPaths, winner)
 
 			overrides := findFiles(DefaultOverrideFileNames, pwd)
 			if len(overrides) > 0 {
 				if len(overrides) > 1 {
-					logrus.Warnf("Found multiple override files with supported names: %s", strings.Join(overrides, ", "))
-					logrus.Warnf("Using %s", overrides[0])
+					o.sink().Report(diagnostics.Diagnostic{
+						Code:    diagnostics.MultipleOverrideFiles,
+						Message: fmt.Sprintf("Found multiple override files with supported names: %s. Using %s", strings.Join(overrides, ", "), overrides[0]),
+						File:    overrides[0],
+					})
 				}
 				o.ConfigPaths = append(o.ConfigPaths, overrides[0])
 			}
@@ -184,6 +201,22 @@ func WithDefaultConfigPath(o *ProjectOptions) error {
 	}
 }
 
+// WithDiagnostics sets the Sink receiving warnings, instead of logging them
+func WithDiagnostics(sink diagnostics.Sink) ProjectOptionsFn {
+	return func(o *ProjectOptions) error {
+		o.Diagnostics = sink
+		return nil
+	}
+}
+
+// sink returns the Sink diagnostics are reported to
+func (o *ProjectOptions) sink() diagnostics.Sink {
+	if o.Diagnostics == nil {
+		return diagnostics.Logrus
+	}
+	return o.Diagnostics
+}
+
 // WithEnv defines a key=value set of variables used for compose file interpolation
 func WithEnv(env []string) ProjectOptionsFn {
 	return func(o *ProjectOptions) error {
@@ -197,6 +230,7 @@ func WithEnv(env []string) ProjectOptionsFn {
 // WithDiscardEnvFile sets discards the `env_file` section after resolving to
 // the `environment` section
 func WithDiscardEnvFile(o *ProjectOptions) error {
+	o.discardEnvFiles = true
 	o.loadOptions = append(o.loadOptions, loader.WithDiscardEnvFiles)
 	return nil
 }
@@ -267,6 +301,20 @@ func WithDotEnv(o *ProjectOptions) error {
 		return err
 	}
 	o.Environment.Merge(envMap)
+
+	dotEnvFiles := o.EnvFiles
+	if len(dotEnvFiles) == 0 {
+		dotEnvFiles = []string{filepath.Join(wd, ".env")}
+	}
+	for _, f := range dotEnvFiles {
+		f, err := filepath.Abs(f)
+		if err != nil {
+			return err
+		}
+		if s, err := os.Stat(f); err == nil && s.Mode().IsRegular() {
+			o.dotEnvFiles = append(o.dotEnvFiles, f)
+		}
+	}
 	return nil
 }
 
@@ -399,6 +447,11 @@ func ProjectFromOptions(options *ProjectOptions) (*types.Project, error) {
 	}
 
 	options.loadOptions = append(options.loadOptions,
+		func(opts *loader.Options) {
+			if opts.Diagnostics == nil {
+				opts.Diagnostics = options.Diagnostics
+			}
+		},
 		withNamePrecedenceLoad(absWorkingDir, options),
 		withConvertWindowsPaths(options))
 
@@ -417,6 +470,13 @@ func ProjectFromOptions(options *ProjectOptions) (*types.Project, error) {
 	}
 
 	project.ComposeFiles = configPaths
+	for _, f := range options.dotEnvFiles {
+		dotEnv, err := types.ReadInputFile(types.InputFileDotEnv, f)
+		if err != nil {
+			return nil, err
+		}
+		project.InputFiles = append(project.InputFiles, dotEnv)
+	}
 	return project, nil
 }
 
@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/compose-spec/compose-go/v2/diagnostics"
 	"github.com/compose-spec/compose-go/v2/types"
 	"gotest.tools/v3/assert"
 
@@ -291,6 +292,64 @@ func TestProjectWithMultipleEnvFile(t *testing.T) {
 	assert.Equal(t, service.Ports[0].Published, "9000")
 }
 
+func TestProjectInputFiles(t *testing.T) {
+	opts, err := NewProjectOptions([]string{
+		"testdata/env-file/compose-with-env-file.yaml",
+	}, WithDiscardEnvFile, WithDotEnv)
+	assert.NilError(t, err)
+	p, err := ProjectFromOptions(opts)
+	assert.NilError(t, err)
+
+	abs := func(p string) string {
+		f, err := filepath.Abs(p)
+		assert.NilError(t, err)
+		return f
+	}
+	var files []string
+	for _, f := range p.InputFiles {
+		files = append(files, fmt.Sprintf("%s %s", f.Kind, f.Path))
+		assert.Check(t, f.Digest.Validate() == nil)
+	}
+	assert.DeepEqual(t, files, []string{
+		"compose " + abs("testdata/env-file/compose-with-env-file.yaml"),
+		"env_file " + abs("testdata/env-file/simple-env"),
+		"dotenv " + abs("testdata/env-file/.env"),
+	})
+}
+
+func TestProjectWithDiagnostics(t *testing.T) {
+	wd := t.TempDir()
+	for _, f := range []string{"compose.yaml", "docker-compose.yml"} {
+		err := os.WriteFile(filepath.Join(wd, f), []byte("services:\n  web:\n    image: nginx:$TAG\n"), 0o700)
+		assert.NilError(t, err)
+	}
+	collector := &diagnostics.Collector{}
+	opts, err := NewProjectOptions(nil,
+		WithName("diagnostics"),
+		WithWorkingDirectory(wd),
+		WithDiagnostics(collector),
+		WithDefaultConfigPath)
+	assert.NilError(t, err)
+	_, err = ProjectFromOptions(opts)
+	assert.NilError(t, err)
+
+	winner := filepath.Join(wd, "compose.yaml")
+	assert.DeepEqual(t, collector.Diagnostics(), []diagnostics.Diagnostic{
+		{
+			Code: diagnostics.MultipleConfigFiles,
+			Message: fmt.Sprintf("Found multiple config files with supported names: %s, %s. Using %s",
+				winner, filepath.Join(wd, "docker-compose.yml"), winner),
+			File: winner,
+		},
+		{
+			Code:    diagnostics.VariableNotSet,
+			Message: `The "TAG" variable is not set. Defaulting to a blank string.`,
+			Path:    "services.web.image",
+			File:    winner,
+		},
+	})
+}
+
 func TestProjectNameFromWorkingDir(t *testing.T) {
 	opts, err := NewProjectOptions([]string{
 		"testdata/env-file/compose-with-env-file.yaml",
@@ -0,0 +1,256 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/dotenv"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// WatchFunc is notified with the reloaded project and the changes compared to the
+// previous successful load, or the error reported while reloading
+type WatchFunc func(project *types.Project, diff types.ProjectDiff, err error)
+
+// WatchOptions control how WatchProject detects changes
+type WatchOptions struct {
+	// Interval between checks for file changes
+	Interval time.Duration
+	// Debounce is the delay without further changes to wait before project is reloaded
+	Debounce time.Duration
+	// DevelopWatch also watches paths declared by services' `develop.watch` section
+	DevelopWatch bool
+}
+
+// WithWatchInterval sets the interval between checks for file changes
+func WithWatchInterval(interval time.Duration) func(*WatchOptions) {
+	return func(o *WatchOptions) {
+		o.Interval = interval
+	}
+}
+
+// WithWatchDebounce sets the delay without further changes before project is reloaded
+func WithWatchDebounce(debounce time.Duration) func(*WatchOptions) {
+	return func(o *WatchOptions) {
+		o.Debounce = debounce
+	}
+}
+
+// WithDevelopWatch sets WatchOptions to also watch paths declared by `develop.watch`
+func WithDevelopWatch(o *WatchOptions) {
+	o.DevelopWatch = true
+}
+
+// WatchProject loads a compose project then watches all files which contributed to the load:
+// compose files, overrides, includes, extends targets, `.env` and services' `env_file`,
+// and optionally `develop.watch` paths. On change, project is reloaded and onChange notified.
+//
+// onChange is first invoked with the initial load, then after each reload. WatchProject blocks
+// until ctx is done.
+func WatchProject(ctx context.Context, options *ProjectOptions, onChange WatchFunc, opts ...func(*WatchOptions)) error {
+	watchOptions := WatchOptions{
+		Interval: time.Second,
+		Debounce: 300 * time.Millisecond,
+	}
+	for _, o := range opts {
+		o(&watchOptions)
+	}
+	w := &projectWatcher{
+		options:      options,
+		watchOptions: watchOptions,
+		environment:  options.Environment.Clone(),
+	}
+	// values set by WithDotEnv are removed from base environment, so that a change to `.env` applies on reload
+	if wd, err := options.GetWorkingDir(); err == nil {
+		if envMap, err := dotenv.GetEnvFromFile(options.Environment, wd, options.EnvFiles); err == nil {
+			for k, v := range envMap {
+				if w.environment[k] == v {
+					delete(w.environment, k)
+				}
+			}
+		}
+	}
+
+	project, files, err := w.load()
+	var previous *types.Project
+	onChange(project, previous.Diff(project), err)
+	if err == nil {
+		previous = project
+	}
+	state := w.snapshot(files)
+
+	ticker := time.NewTicker(watchOptions.Interval)
+	defer ticker.Stop()
+	var changed time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			current := w.snapshot(files)
+			if !reflect.DeepEqual(current, state) {
+				state = current
+				changed = now
+				continue
+			}
+			if changed.IsZero() || now.Sub(changed) < watchOptions.Debounce {
+				continue
+			}
+			changed = time.Time{}
+
+			project, reloaded, err := w.load()
+			if err != nil {
+				onChange(nil, types.ProjectDiff{}, err)
+				continue
+			}
+			diff := previous.Diff(project)
+			previous = project
+			files = reloaded
+			state = w.snapshot(files)
+			onChange(project, diff, nil)
+		}
+	}
+}
+
+type projectWatcher struct {
+	options      *ProjectOptions
+	watchOptions WatchOptions
+	environment  types.Mapping
+}
+
+// load loads project and collects files which have been used to load it
+func (w *projectWatcher) load() (*types.Project, []string, error) {
+	var (
+		mu    sync.Mutex
+		files []string
+	)
+	track := func(f string) {
+		if f == "" || f == "-" {
+			return
+		}
+		if abs, err := filepath.Abs(f); err == nil {
+			f = abs
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		files = append(files, f)
+	}
+
+	o := *w.options
+	o.Environment = w.environment.Clone()
+	o.loadOptions = append(append([]func(*loader.Options){}, w.options.loadOptions...), func(opts *loader.Options) {
+		opts.Listeners = append(opts.Listeners, func(event string, metadata map[string]any) {
+			switch event {
+			case loader.EventLoad, loader.EventEnvFile:
+				track(metadata["file"].(string))
+			case loader.EventInclude:
+				envFiles := metadata["env_file"].(types.StringList)
+				if len(envFiles) == 0 {
+					track(filepath.Join(metadata["project_directory"].(string), ".env"))
+				}
+				for _, f := range envFiles {
+					track(f)
+				}
+			}
+		})
+	})
+
+	for _, f := range o.ConfigPaths {
+		track(f)
+	}
+	wd, err := o.GetWorkingDir()
+	if err != nil {
+		return nil, files, err
+	}
+	envFiles := o.EnvFiles
+	if len(envFiles) == 0 {
+		envFiles = []string{filepath.Join(wd, ".env")}
+	}
+	for _, f := range envFiles {
+		track(f)
+	}
+
+	// reload .env as WithDotEnv only ran once while options were created
+	envMap, err := dotenv.GetEnvFromFile(o.Environment, wd, o.EnvFiles)
+	if err != nil {
+		return nil, files, err
+	}
+	o.Environment.Merge(envMap)
+
+	project, err := ProjectFromOptions(&o)
+	if err != nil {
+		return nil, files, err
+	}
+	if w.watchOptions.DevelopWatch {
+		for _, s := range project.Services {
+			if s.Develop == nil {
+				continue
+			}
+			for _, trigger := range s.Develop.Watch {
+				track(trigger.Path)
+			}
+		}
+	}
+	return project, files, nil
+}
+
+// fileState is the fingerprint used to detect a file change
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	entries int
+}
+
+// snapshot captures the current state of watched files. Directories are walked so that
+// any change to their content is detected
+func (w *projectWatcher) snapshot(files []string) map[string]fileState {
+	state := map[string]fileState{}
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			state[f] = fileState{}
+			continue
+		}
+		s := fileState{exists: true, modTime: info.ModTime(), size: info.Size()}
+		if info.IsDir() {
+			_ = filepath.WalkDir(f, func(_ string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				s.entries++
+				if i, err := d.Info(); err == nil {
+					if i.ModTime().After(s.modTime) {
+						s.modTime = i.ModTime()
+					}
+					s.size += i.Size()
+				}
+				return nil
+			})
+		}
+		state[f] = s
+	}
+	return state
+}
@@ -0,0 +1,110 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+type watchEvent struct {
+	project *types.Project
+	diff    types.ProjectDiff
+	err     error
+}
+
+func TestWatchProject(t *testing.T) {
+	wd := t.TempDir()
+	write := func(name, content string) {
+		assert.NilError(t, os.WriteFile(filepath.Join(wd, name), []byte(content), 0o600))
+	}
+	write("compose.yaml", `
+name: watch
+include:
+  - included.yaml
+services:
+  web:
+    image: nginx:${TAG}
+    env_file: web.env
+`)
+	write("included.yaml", `
+services:
+  db:
+    image: postgres
+`)
+	write(".env", "TAG=1")
+	write("web.env", "FOO=BAR")
+
+	options, err := NewProjectOptions([]string{filepath.Join(wd, "compose.yaml")}, WithDotEnv)
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan watchEvent, 10)
+	go func() {
+		_ = WatchProject(ctx, options, func(project *types.Project, diff types.ProjectDiff, err error) {
+			events <- watchEvent{project: project, diff: diff, err: err}
+		}, WithWatchInterval(10*time.Millisecond), WithWatchDebounce(20*time.Millisecond))
+	}()
+
+	next := func() watchEvent {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for project reload")
+		}
+		return watchEvent{}
+	}
+
+	e := next()
+	assert.NilError(t, e.err)
+	assert.DeepEqual(t, e.diff.Services.Added, []string{"db", "web"})
+	assert.Equal(t, e.project.Services["web"].Image, "nginx:1")
+
+	write(".env", "TAG=22")
+	e = next()
+	assert.NilError(t, e.err)
+	assert.Equal(t, e.project.Services["web"].Image, "nginx:22")
+	assert.DeepEqual(t, e.diff.Services.Modified, []string{"web"})
+
+	write("included.yaml", `
+services:
+  cache:
+    image: redis
+`)
+	e = next()
+	assert.NilError(t, e.err)
+	assert.DeepEqual(t, e.diff.Services.Added, []string{"cache"})
+	assert.DeepEqual(t, e.diff.Services.Removed, []string{"db"})
+
+	write("web.env", "FOO=ZOT")
+	e = next()
+	assert.NilError(t, e.err)
+	assert.Equal(t, *e.project.Services["web"].Environment["FOO"], "ZOT")
+
+	write("compose.yaml", "services: [")
+	e = next()
+	assert.Check(t, e.err != nil)
+}
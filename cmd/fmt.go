@@ -0,0 +1,75 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/compose-spec/compose-go/v2/editor"
+)
+
+// formatCommand formats compose files, and returns the process exit code
+func formatCommand(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	check := flags.Bool("check", false, "list files which are not formatted and exit with a non-zero status")
+	write := flags.Bool("w", false, "write result to source file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: compose-spec fmt [--check] [-w] COMPOSE_FILE...")
+		return 2
+	}
+
+	status := 0
+	for _, file := range flags.Args() {
+		e, err := editor.Open(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		original, err := e.Bytes()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		e.Format()
+		formatted, err := e.Bytes()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		switch {
+		case *check:
+			if !bytes.Equal(original, formatted) {
+				fmt.Println(file)
+				status = 1
+			}
+		case *write:
+			if err := e.Write(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		default:
+			fmt.Print(string(formatted))
+		}
+	}
+	return status
+}
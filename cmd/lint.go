@@ -0,0 +1,79 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/compose-spec/compose-go/v2/lint"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/override"
+)
+
+// lintCommand checks a compose project against best practices, and returns the process exit code
+func lintCommand(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	failOn := flags.String("fail-on", "error", "minimum severity of findings to exit with a non-zero status (info, warning, error)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	threshold, err := lint.ParseSeverity(*failOn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	project, err := loadProject(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	// x-lint-ignore is looked up in the compose files as written by user
+	dict := map[string]any{}
+	for _, file := range project.ComposeFiles {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		model, err := loader.ParseYAML(content)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "parsing %s: %s\n", file, err)
+			return 1
+		}
+		if dict, err = override.Merge(dict, model); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	findings, err := lint.Lint(dict, project)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	status := 0
+	for _, f := range findings {
+		fmt.Println(f)
+		if f.Severity >= threshold {
+			status = 1
+		}
+	}
+	return status
+}
@@ -21,45 +21,91 @@ import (
 	"os"
 
 	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/types"
 )
 
+const usage = `
+Validates a compose file conforms to the Compose Specification
+
+Usage: compose-spec [OPTIONS] COMPOSE_FILE [COMPOSE_OVERRIDE_FILE]
+       compose-spec fmt [--check] [-w] COMPOSE_FILE...
+       compose-spec migrate [-w] COMPOSE_FILE...
+       compose-spec lint [--fail-on SEVERITY] [COMPOSE_FILE...]
+       compose-spec policy -p POLICY_FILE... [COMPOSE_FILE...]
+       compose-spec resources [--by-profile] [COMPOSE_FILE...]
+       compose-spec min-version [--target VERSION] [COMPOSE_FILE...]
+       compose-spec schema [--check [--all]]
+
+Commands:
+  fmt          Format compose files
+  migrate      Rewrite legacy compose files using current syntax
+  lint         Check compose project against best practices
+  policy       Check compose project against CEL policies
+  resources    Report resources required by compose project
+  min-version  Report the minimum specification version compose project requires
+  schema       Generate the JSON schema for the Go model, or check it against the specification`
+
 func main() {
 	if len(os.Args) == 1 {
-		fmt.Println(`
-Validates a compose file conforms to the Compose Specification
+		fmt.Println(usage)
+	}
 
-Usage: compose-spec [OPTIONS] COMPOSE_FILE [COMPOSE_OVERRIDE_FILE]`)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			os.Exit(formatCommand(os.Args[2:]))
+		case "migrate":
+			os.Exit(migrateCommand(os.Args[2:]))
+		case "lint":
+			os.Exit(lintCommand(os.Args[2:]))
+		case "policy":
+			os.Exit(policyCommand(os.Args[2:]))
+		case "resources":
+			os.Exit(resourcesCommand(os.Args[2:]))
+		case "min-version":
+			os.Exit(minVersionCommand(os.Args[2:]))
+		case "schema":
+			os.Exit(schemaCommand(os.Args[2:]))
+		}
 	}
 
-	wd, err := os.Getwd()
+	project, err := loadProject(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	yaml, err := project.MarshalYAML()
 	if err != nil {
-		exitError("can't determine current directory", err)
+		exitError("failed to marshall project", err)
 	}
+	fmt.Println(string(yaml))
+}
 
-	options, err := cli.NewProjectOptions(os.Args[1:],
+// loadProject loads the compose project set by files, or by the default compose file
+func loadProject(files []string, opts ...cli.ProjectOptionsFn) (*types.Project, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("can't determine current directory: %w", err)
+	}
+	options, err := cli.NewProjectOptions(files, append([]cli.ProjectOptionsFn{
 		cli.WithWorkingDirectory(wd),
 		cli.WithOsEnv,
 		cli.WithDotEnv,
 		cli.WithConfigFileEnv,
 		cli.WithDefaultConfigPath,
-	)
+	}, opts...)...)
 	if err != nil {
-		exitError("failed to configure project options", err)
+		return nil, fmt.Errorf("failed to configure project options: %w", err)
 	}
-
 	project, err := cli.ProjectFromOptions(options)
 	if err != nil {
-		exitError("failed to load project", err)
+		return nil, fmt.Errorf("failed to load project: %w", err)
 	}
-
-	yaml, err := project.MarshalYAML()
-	if err != nil {
-		exitError("failed to marshall project", err)
-	}
-	fmt.Println(string(yaml))
+	return project, nil
 }
 
 func exitError(message string, err error) {
-	_ = fmt.Errorf("%s: %w", message, err)
+	fmt.Fprintf(os.Stderr, "%s: %s\n", message, err)
 	os.Exit(1)
 }
@@ -0,0 +1,70 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/compose-spec/compose-go/v2/editor"
+	"github.com/compose-spec/compose-go/v2/migrate"
+)
+
+// migrateCommand rewrites legacy compose files using current syntax, and returns the process exit code
+func migrateCommand(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write result to source file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: compose-spec migrate [-w] COMPOSE_FILE...")
+		return 2
+	}
+
+	for _, file := range flags.Args() {
+		e, err := editor.Open(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		changes, err := migrate.Migrate(e)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			return 1
+		}
+		// report is printed on stderr, so that stdout can be redirected to a file
+		for _, change := range changes {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, change)
+		}
+		if *write {
+			if err := e.Write(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			continue
+		}
+		migrated, err := e.Bytes()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Print(string(migrated))
+	}
+	return 0
+}
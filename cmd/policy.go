@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/policy"
+)
+
+// files is a flag.Value collecting file paths set by a repeated flag
+type files []string
+
+func (f *files) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *files) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// policyCommand checks a compose project against policies, and returns the process exit code
+func policyCommand(args []string) int {
+	flags := flag.NewFlagSet("policy", flag.ContinueOnError)
+	var policyFiles files
+	flags.Var(&policyFiles, "p", "policies file (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if len(policyFiles) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: compose-spec policy -p POLICY_FILE... [COMPOSE_FILE...]")
+		return 2
+	}
+
+	var policies []policy.Policy
+	for _, file := range policyFiles {
+		p, err := policy.Load(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		policies = append(policies, p...)
+	}
+	evaluator, err := policy.NewEvaluator(policies...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	project, err := loadProject(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	violations, err := evaluator.Evaluate(project)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, v := range violations {
+		fmt.Println(v)
+	}
+	if len(violations) > 0 {
+		return 1
+	}
+	return 0
+}
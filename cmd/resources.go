@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/go-units"
+)
+
+// resourcesCommand reports the resources required by a compose project, and returns the process exit code
+func resourcesCommand(args []string) int {
+	flags := flag.NewFlagSet("resources", flag.ContinueOnError)
+	byProfile := flags.Bool("by-profile", false, "report resources for services enabled by each profile")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	project, err := loadProject(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if !*byProfile {
+		printResourceSummary(os.Stdout, project.ResourceSummary())
+		return 0
+	}
+	summaries := project.ResourceSummaryByProfile()
+	profiles := make([]string, 0, len(summaries))
+	for profile := range summaries {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	for i, profile := range profiles {
+		if i > 0 {
+			fmt.Println()
+		}
+		if profile == "" {
+			fmt.Println("(no profile)")
+		} else {
+			fmt.Printf("profile %s\n", profile)
+		}
+		printResourceSummary(os.Stdout, summaries[profile])
+	}
+	return 0
+}
+
+func printResourceSummary(out io.Writer, summary types.ResourceSummary) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, "services\t%d\n", summary.Services)
+	fmt.Fprintf(w, "containers\t%d\n", summary.Containers)
+	fmt.Fprintln(w, "\tlimits\treservations")
+	fmt.Fprintf(w, "cpus\t%s\t%s\n", summary.Limits.NanoCPUs, summary.Reservations.NanoCPUs)
+	fmt.Fprintf(w, "memory\t%s\t%s\n",
+		units.BytesSize(float64(summary.Limits.MemoryBytes)),
+		units.BytesSize(float64(summary.Reservations.MemoryBytes)))
+	fmt.Fprintf(w, "pids\t%d\t%d\n", summary.Limits.Pids, summary.Reservations.Pids)
+	for _, kind := range sortedKeys(summary.Limits.Devices, summary.Reservations.Devices) {
+		fmt.Fprintf(w, "devices (%s)\t%s\t%s\n", kind,
+			deviceCount(summary.Limits.Devices[kind]), deviceCount(summary.Reservations.Devices[kind]))
+	}
+	for _, kind := range sortedKeys(summary.Limits.GenericResources, summary.Reservations.GenericResources) {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", kind,
+			summary.Limits.GenericResources[kind], summary.Reservations.GenericResources[kind])
+	}
+	if len(summary.Global) > 0 {
+		fmt.Fprintf(w, "global\t%s (per node)\n", strings.Join(summary.Global, ", "))
+	}
+	if len(summary.Unlimited) > 0 {
+		fmt.Fprintf(w, "no limits\t%s\n", strings.Join(summary.Unlimited, ", "))
+	}
+}
+
+func deviceCount(count types.DeviceCount) string {
+	if count < 0 {
+		return "all"
+	}
+	return fmt.Sprint(count)
+}
+
+func sortedKeys[V any](maps ...map[string]V) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,64 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/compose-spec/compose-go/v2/typeschema"
+)
+
+// schemaCommand emits the JSON schema for the Go model, or checks it is consistent with the Compose Specification
+// JSON schema, and returns the process exit code
+func schemaCommand(args []string) int {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+	check := flags.Bool("check", false, "report attributes only declared by either the Go model or the Compose Specification")
+	all := flags.Bool("all", false, "with --check, also report the known drift, which doesn't cause a non-zero status")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *check {
+		mismatches, err := typeschema.Check()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		reported := typeschema.WithoutKnownDrift(mismatches)
+		status := 0
+		if len(reported) > 0 {
+			status = 1
+		}
+		if *all {
+			reported = mismatches
+		}
+		for _, m := range reported {
+			fmt.Println(m)
+		}
+		return status
+	}
+
+	generated, err := typeschema.Generate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(string(generated))
+	return 0
+}
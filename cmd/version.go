@@ -0,0 +1,121 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// minVersionCommand reports the minimum Compose Specification version a project requires, and returns the process
+// exit code
+func minVersionCommand(args []string) int {
+	flags := flag.NewFlagSet("min-version", flag.ContinueOnError)
+	target := flags.String("target", "", "exit with a non-zero status if project requires a version after target")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// features are detected in compose files as written by user, as loader does to check TargetVersion
+	contents := map[string][]byte{}
+	record := func(event string, metadata map[string]any) {
+		if event == loader.EventLoad {
+			contents[metadata["file"].(string)] = metadata["content"].([]byte)
+		}
+	}
+	_, err := loadProject(flags.Args(), cli.WithLoadOptions(func(options *loader.Options) {
+		options.Listeners = append(options.Listeners, record)
+	}))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	files := make([]string, 0, len(contents))
+	for file := range contents {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	used := map[string][]schema.FeatureUsage{}
+	var all []schema.FeatureUsage
+	for _, file := range files {
+		features, err := usedFeatures(contents[file])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "parsing %s: %s\n", file, err)
+			return 1
+		}
+		used[file] = features
+		all = append(all, features...)
+	}
+
+	if *target != "" {
+		status := 0
+		for _, file := range files {
+			if err := schema.CheckFeaturesVersion(used[file], *target); err != nil {
+				fmt.Fprintf(os.Stderr, "validating %s: %s\n", file, err)
+				status = 1
+			}
+		}
+		if status != 0 {
+			return status
+		}
+	}
+	version := schema.MinimumFeaturesVersion(all)
+	if version == "" {
+		fmt.Println("project is supported by all versions")
+		return 0
+	}
+	fmt.Println(version)
+	for _, file := range files {
+		for _, u := range used[file] {
+			fmt.Printf("  %s: %s requires %s\n", file, u, u.Version)
+		}
+	}
+	return 0
+}
+
+// usedFeatures returns the attributes and tags used by the documents of a compose file which are features
+func usedFeatures(content []byte) ([]schema.FeatureUsage, error) {
+	var used []schema.FeatureUsage
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return used, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		used = append(used, schema.UsedTags(&node)...)
+		var dict map[string]any
+		if err := node.Decode(&dict); err != nil {
+			return nil, err
+		}
+		used = append(used, schema.UsedFeatures(dict)...)
+	}
+}
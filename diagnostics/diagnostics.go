@@ -0,0 +1,110 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package diagnostics reports non-fatal issues detected while loading a compose project, like usage of deprecated
+// attributes
+package diagnostics
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Deprecated is reported when a compose file uses a deprecated attribute
+	Deprecated = "deprecated"
+	// VariableNotSet is reported when interpolation uses a variable which is not set and has no default value
+	VariableNotSet = "variable-not-set"
+	// MultipleConfigFiles is reported when multiple compose files with a supported name are found in a directory
+	MultipleConfigFiles = "multiple-config-files"
+	// MultipleOverrideFiles is reported when multiple override files with a supported name are found in a directory
+	MultipleOverrideFiles = "multiple-override-files"
+)
+
+// Diagnostic is a warning about a compose file
+type Diagnostic struct {
+	// Code identifies the kind of diagnostic
+	Code string
+	// Message is a human-readable description
+	Message string
+	// Path is the attribute diagnostic relates to, if any
+	Path tree.Path
+	// File is the compose file diagnostic relates to, if known
+	File string
+}
+
+func (d Diagnostic) String() string {
+	var parts []string
+	if d.File != "" {
+		parts = append(parts, d.File)
+	}
+	if d.Path != "" {
+		parts = append(parts, string(d.Path))
+	}
+	return strings.Join(append(parts, d.Message), ": ")
+}
+
+// Sink receives diagnostics
+type Sink interface {
+	Report(d Diagnostic)
+}
+
+// SinkFunc adapts a function to the Sink interface
+type SinkFunc func(d Diagnostic)
+
+func (f SinkFunc) Report(d Diagnostic) {
+	f(d)
+}
+
+// Logrus is the default Sink, which logs diagnostics as warnings
+var Logrus Sink = SinkFunc(func(d Diagnostic) {
+	logrus.Warn(d.String())
+})
+
+// Discard is a Sink which ignores diagnostics
+var Discard Sink = SinkFunc(func(Diagnostic) {})
+
+// WithFile returns a Sink which sets File on diagnostics not bound to a compose file yet, before reporting to sink
+func WithFile(sink Sink, file string) Sink {
+	return SinkFunc(func(d Diagnostic) {
+		if d.File == "" {
+			d.File = file
+		}
+		sink.Report(d)
+	})
+}
+
+// Collector is a Sink which records diagnostics
+type Collector struct {
+	mu          sync.Mutex
+	diagnostics []Diagnostic
+}
+
+func (c *Collector) Report(d Diagnostic) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+// Diagnostics returns the diagnostics recorded so far
+func (c *Collector) Diagnostics() []Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Diagnostic(nil), c.diagnostics...)
+}
@@ -0,0 +1,359 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package editor allows to edit a compose file programmatically, preserving comments, anchors and attributes order
+package editor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/internal/yamlnode"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Editor edits a compose file as a yaml.Node tree
+type Editor struct {
+	file     string
+	content  []byte
+	document *yaml.Node
+	layout   *layout
+	indent   int
+	modified bool
+	// reformat is set when the whole compose file must be encoded, rather than only edited entries
+	reformat bool
+}
+
+// Open parses compose file for edition
+func Open(file string) (*Editor, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	e, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	e.file = file
+	return e, nil
+}
+
+// Parse parses compose file content for edition
+func Parse(content []byte) (*Editor, error) {
+	document := &yaml.Node{}
+	if err := yaml.Unmarshal(content, document); err != nil {
+		return nil, err
+	}
+	if document.Kind == 0 {
+		// empty file
+		document = &yaml.Node{Kind: yaml.DocumentNode}
+	}
+	if len(document.Content) == 0 {
+		document.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	if document.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("Top-level object must be a mapping")
+	}
+	return &Editor{
+		content:  content,
+		document: document,
+		layout:   newLayout(content, document),
+		indent:   detectIndent(content),
+	}, nil
+}
+
+// Root returns the top-level mapping node of the compose file
+func (e *Editor) Root() *yaml.Node {
+	return e.document.Content[0]
+}
+
+// Modified returns true if the compose file has been edited
+func (e *Editor) Modified() bool {
+	return e.modified
+}
+
+// MarkModified flags the compose file as edited, after Root node has been modified directly
+func (e *Editor) MarkModified() {
+	e.modified = true
+}
+
+// Get returns the yaml node at path, following aliases
+func (e *Editor) Get(path tree.Path) (*yaml.Node, error) {
+	node := e.Root()
+	for _, part := range path.Parts() {
+		node = yamlnode.ResolveAlias(node)
+		switch node.Kind {
+		case yaml.MappingNode:
+			value := yamlnode.MappingValue(node, part)
+			if value == nil {
+				return nil, errors.Wrapf(errdefs.ErrNotFound, "%s", path)
+			}
+			node = value
+		case yaml.SequenceNode:
+			i := sequenceIndex(node, part)
+			if i < 0 {
+				return nil, errors.Wrapf(errdefs.ErrNotFound, "%s", path)
+			}
+			node = node.Content[i]
+		default:
+			return nil, errors.Wrapf(errdefs.ErrNotFound, "%s", path)
+		}
+	}
+	return yamlnode.ResolveAlias(node), nil
+}
+
+// Set sets value at path, creating intermediate mappings as needed. Comments set on the node being replaced are
+// kept. In a sequence, items are addressed by index, or by variable name for a `KEY=VALUE` sequence like
+// `environment` declared using the list syntax. An alias traversed by path is replaced by a copy of the anchored
+// node, so that other references to the anchor are not impacted.
+func (e *Editor) Set(path tree.Path, value any) error {
+	parts := path.Parts()
+	if len(parts) == 0 {
+		return errors.New("cannot set compose file root")
+	}
+	node := e.Root()
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch node.Kind {
+		case yaml.MappingNode:
+			idx := yamlnode.MappingIndex(node, part)
+			if last {
+				n, err := encode(value)
+				if err != nil {
+					return err
+				}
+				if idx < 0 {
+					node.Content = append(node.Content, yamlnode.KeyNode(part), n)
+				} else {
+					replace(node, idx+1, n)
+				}
+				e.modified = true
+				return nil
+			}
+			if idx < 0 {
+				child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				if merged := yamlnode.MappingValue(node, part); merged != nil {
+					// value set by a `<<` merge key is copied, so that other attributes are kept
+					child = yamlnode.DeepCopy(yamlnode.ResolveAlias(merged))
+					child.Anchor = ""
+				}
+				node.Content = append(node.Content, yamlnode.KeyNode(part), child)
+				idx = len(node.Content) - 2
+				e.modified = true
+			}
+			node = yamlnode.Unalias(node, idx+1)
+		case yaml.SequenceNode:
+			idx := sequenceIndex(node, part)
+			if last {
+				n, err := encode(value)
+				if err != nil {
+					return err
+				}
+				if _, err := strconv.Atoi(part); err != nil {
+					// KEY=VALUE sequence item
+					item := part
+					if value != nil {
+						item = fmt.Sprintf("%s=%v", part, value)
+					}
+					if n, err = encode(item); err != nil {
+						return err
+					}
+				}
+				if idx < 0 {
+					if _, err := strconv.Atoi(part); err == nil && part != strconv.Itoa(len(node.Content)) {
+						return errors.Wrapf(errdefs.ErrNotFound, "%s", path)
+					}
+					node.Content = append(node.Content, n)
+				} else {
+					replace(node, idx, n)
+				}
+				e.modified = true
+				return nil
+			}
+			if idx < 0 {
+				return errors.Wrapf(errdefs.ErrNotFound, "%s", path)
+			}
+			node = yamlnode.Unalias(node, idx)
+		default:
+			return fmt.Errorf("cannot set %s: %s is not a mapping or sequence", path, tree.NewPath(parts[:i]...))
+		}
+	}
+	return nil
+}
+
+// Delete removes the attribute, or sequence item, at path
+func (e *Editor) Delete(path tree.Path) error {
+	parts := path.Parts()
+	if len(parts) == 0 {
+		return errors.New("cannot delete compose file root")
+	}
+	node := e.Root()
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch node.Kind {
+		case yaml.MappingNode:
+			idx := yamlnode.MappingIndex(node, part)
+			if idx < 0 {
+				return errors.Wrapf(errdefs.ErrNotFound, "%s", path)
+			}
+			if last {
+				node.Content = append(node.Content[:idx], node.Content[idx+2:]...)
+				e.modified = true
+				return nil
+			}
+			node = yamlnode.Unalias(node, idx+1)
+		case yaml.SequenceNode:
+			idx := sequenceIndex(node, part)
+			if idx < 0 {
+				return errors.Wrapf(errdefs.ErrNotFound, "%s", path)
+			}
+			if last {
+				node.Content = append(node.Content[:idx], node.Content[idx+1:]...)
+				e.modified = true
+				return nil
+			}
+			node = yamlnode.Unalias(node, idx)
+		default:
+			return errors.Wrapf(errdefs.ErrNotFound, "%s", path)
+		}
+	}
+	return nil
+}
+
+// AddService adds a service definition to the compose file
+func (e *Editor) AddService(name string, service types.ServiceConfig) error {
+	if _, err := e.Get(tree.NewPath("services", name)); err == nil {
+		return fmt.Errorf("service %q already exists", name)
+	}
+	return e.Set(tree.NewPath("services", name), service)
+}
+
+// Bytes returns the edited compose file content. Content is returned unchanged if no edition took place.
+// Otherwise, only the mapping entries and sequence items which have been edited are encoded, other lines being kept
+// byte-identical, unless edits can't be isolated from the surrounding content (typically within flow style
+// collections) in which case the enclosing entry is encoded.
+func (e *Editor) Bytes() ([]byte, error) {
+	if !e.modified {
+		return e.content, nil
+	}
+	if !e.reformat {
+		content, ok, err := e.layout.splice(e.document, e.indent)
+		if err != nil || ok {
+			return content, err
+		}
+	}
+	return encodeNode(e.document, e.indent)
+}
+
+// Write saves the edited compose file
+func (e *Editor) Write() error {
+	if e.file == "" {
+		return errors.New("compose file has not been opened from a file")
+	}
+	if !e.modified {
+		return nil
+	}
+	content, err := e.Bytes()
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if stat, err := os.Stat(e.file); err == nil {
+		mode = stat.Mode()
+	}
+	if err := os.WriteFile(e.file, content, mode); err != nil {
+		return err
+	}
+	// parse saved content, so that further edits are spliced into it
+	saved, err := Parse(content)
+	if err != nil {
+		return err
+	}
+	e.content = saved.content
+	e.document = saved.document
+	e.layout = saved.layout
+	e.modified = false
+	e.reformat = false
+	return nil
+}
+
+// encode converts value into a yaml node
+func encode(value any) (*yaml.Node, error) {
+	if n, ok := value.(*yaml.Node); ok {
+		return n, nil
+	}
+	n := &yaml.Node{}
+	if err := n.Encode(value); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// replace sets node as parent's child at index i, keeping comments and scalar style set on the replaced node
+func replace(parent *yaml.Node, i int, node *yaml.Node) {
+	old := parent.Content[i]
+	if node.HeadComment == "" {
+		node.HeadComment = old.HeadComment
+	}
+	if node.LineComment == "" {
+		node.LineComment = old.LineComment
+	}
+	if node.FootComment == "" {
+		node.FootComment = old.FootComment
+	}
+	if old.Kind == yaml.ScalarNode && node.Kind == yaml.ScalarNode && node.Tag == old.Tag && node.Style == 0 {
+		node.Style = old.Style
+	}
+	parent.Content[i] = node
+}
+
+// sequenceIndex returns the index of item in sequence node content, by position or by `KEY=VALUE` name
+func sequenceIndex(node *yaml.Node, part string) int {
+	if i, err := strconv.Atoi(part); err == nil {
+		if i >= 0 && i < len(node.Content) {
+			return i
+		}
+		return -1
+	}
+	for i, item := range node.Content {
+		if item.Kind != yaml.ScalarNode {
+			continue
+		}
+		key, _, _ := strings.Cut(item.Value, "=")
+		if key == part {
+			return i
+		}
+	}
+	return -1
+}
+
+// detectIndent guesses indentation used by content, by looking at the first indented line
+func detectIndent(content []byte) int {
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == line || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		return len(line) - len(trimmed)
+	}
+	return 2
+}
@@ -0,0 +1,281 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+const composeFile = `# my application
+name: myapp
+x-env: &env
+  LOG_LEVEL: info
+services:
+  # the web frontend
+  web:
+    image: nginx:1.24 # pinned
+    environment:
+      - FOO=bar
+    ports:
+      - "8080:80"
+  worker:
+    image: worker
+    environment: *env
+`
+
+func TestEditor(t *testing.T) {
+	e, err := Parse([]byte(composeFile))
+	assert.NilError(t, err)
+
+	assert.NilError(t, e.Set("services.web.image", "nginx:1.25"))
+	assert.NilError(t, e.Set("services.web.environment.ZOT", "qix"))
+	assert.NilError(t, e.Set("services.web.environment.FOO", "baz"))
+	assert.NilError(t, e.Set("services.worker.environment.DEBUG", "true"))
+	assert.NilError(t, e.Delete("services.web.ports"))
+	assert.NilError(t, e.AddService("db", types.ServiceConfig{
+		Name:  "db",
+		Image: "postgres",
+	}))
+
+	content, err := e.Bytes()
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `# my application
+name: myapp
+x-env: &env
+  LOG_LEVEL: info
+services:
+  # the web frontend
+  web:
+    image: nginx:1.25 # pinned
+    environment:
+      - FOO=baz
+      - ZOT=qix
+  worker:
+    image: worker
+    environment:
+      LOG_LEVEL: info
+      DEBUG: "true"
+  db:
+    image: postgres
+`)
+}
+
+func TestEditorUnmodified(t *testing.T) {
+	content := []byte("services:\n    web:\n        image: nginx\n")
+	e, err := Parse(content)
+	assert.NilError(t, err)
+	out, err := e.Bytes()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, out, content)
+
+	// indentation is preserved
+	assert.NilError(t, e.Set("services.web.image", "nginx:alpine"))
+	out, err = e.Bytes()
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), "services:\n    web:\n        image: nginx:alpine\n")
+}
+
+func TestEditorSplice(t *testing.T) {
+	e, err := Parse([]byte(`# my application
+
+name: myapp
+
+services:
+  # the web frontend
+
+  web:
+    image:   nginx   # pinned
+    ports: [ "8080:80" ]
+    environment:
+      - FOO=bar
+
+
+  worker:
+    image: worker
+    command: >
+      run
+      --verbose
+    deploy: {replicas: 2}
+
+# storage
+volumes:
+    data: {}
+    cache:
+        driver: local
+
+# trailing comment
+`))
+	assert.NilError(t, err)
+
+	assert.NilError(t, e.Set("services.web.environment.ZOT", "qix"))
+	assert.NilError(t, e.Set("services.web.ports.1", "9090:90"))
+	assert.NilError(t, e.Set("services.worker.deploy.replicas", 3))
+	assert.NilError(t, e.Set("volumes.cache.driver_opts", map[string]string{"type": "tmpfs"}))
+	assert.NilError(t, e.Delete("volumes.data"))
+
+	content, err := e.Bytes()
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `# my application
+
+name: myapp
+
+services:
+  # the web frontend
+
+  web:
+    image:   nginx   # pinned
+    ports: ["8080:80", '9090:90']
+    environment:
+      - FOO=bar
+      - ZOT=qix
+
+
+  worker:
+    image: worker
+    command: >
+      run
+      --verbose
+    deploy: {replicas: 3}
+
+# storage
+volumes:
+    cache:
+        driver: local
+        driver_opts:
+            type: tmpfs
+
+# trailing comment
+`)
+}
+
+func TestEditorSpliceNoFinalNewLine(t *testing.T) {
+	e, err := Parse([]byte("services:\n  web:\n    image: nginx"))
+	assert.NilError(t, err)
+	assert.NilError(t, e.Set("services.web.init", true))
+	content, err := e.Bytes()
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "services:\n  web:\n    image: nginx\n    init: true\n")
+}
+
+func TestEditorSpliceFooter(t *testing.T) {
+	const content = `services:
+  web:
+    image: nginx
+  db:
+    image: postgres
+    ports: ["5432"]
+# footer
+`
+	e, err := Parse([]byte(content))
+	assert.NilError(t, err)
+	assert.NilError(t, e.Set("services.db.ports.1", "5433"))
+	out, err := e.Bytes()
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), `services:
+  web:
+    image: nginx
+  db:
+    image: postgres
+    ports: ["5432", "5433"]
+# footer
+`)
+
+	e, err = Parse([]byte(content))
+	assert.NilError(t, err)
+	assert.NilError(t, e.AddService("cache", types.ServiceConfig{Image: "redis"}))
+	out, err = e.Bytes()
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), `services:
+  web:
+    image: nginx
+  db:
+    image: postgres
+    ports: ["5432"]
+  cache:
+    image: redis
+# footer
+`)
+}
+
+func TestEditorSpliceEmptyFile(t *testing.T) {
+	e, err := Parse([]byte("# empty\n"))
+	assert.NilError(t, err)
+	assert.NilError(t, e.Set("name", "myapp"))
+	content, err := e.Bytes()
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "# empty\nname: myapp\n")
+}
+
+func TestEditorMergeKey(t *testing.T) {
+	e, err := Parse([]byte(`
+x-base: &base
+  image: nginx
+  environment:
+    FOO: bar
+services:
+  web:
+    <<: *base
+`))
+	assert.NilError(t, err)
+	image, err := e.Get("services.web.image")
+	assert.NilError(t, err)
+	assert.Equal(t, image.Value, "nginx")
+
+	assert.NilError(t, e.Set("services.web.environment.ZOT", "qix"))
+	env, err := e.Get("services.web.environment")
+	assert.NilError(t, err)
+	assert.Equal(t, len(env.Content), 4)
+	base, err := e.Get("x-base.environment")
+	assert.NilError(t, err)
+	assert.Equal(t, len(base.Content), 2)
+}
+
+func TestEditorErrors(t *testing.T) {
+	e, err := Parse([]byte(composeFile))
+	assert.NilError(t, err)
+	err = e.Delete("services.unknown")
+	assert.Check(t, errdefs.IsNotFoundError(err))
+	err = e.Set("services.web.image.tag", "latest")
+	assert.ErrorContains(t, err, "services.web.image is not a mapping or sequence")
+	err = e.AddService("web", types.ServiceConfig{Image: "nginx"})
+	assert.ErrorContains(t, err, `service "web" already exists`)
+
+	_, err = Parse([]byte("- foo"))
+	assert.ErrorContains(t, err, "Top-level object must be a mapping")
+}
+
+func TestEditorWrite(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "compose.yaml")
+	assert.NilError(t, os.WriteFile(file, []byte(composeFile), 0o600))
+	e, err := Open(file)
+	assert.NilError(t, err)
+	assert.NilError(t, e.Set("services.web.image", "nginx:1.25"))
+	assert.NilError(t, e.Write())
+
+	e, err = Open(file)
+	assert.NilError(t, err)
+	image, err := e.Get("services.web.image")
+	assert.NilError(t, err)
+	assert.Equal(t, image.Value, "nginx:1.25")
+	assert.Equal(t, image.LineComment, "# pinned")
+}
@@ -0,0 +1,241 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package editor
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/internal/yamlnode"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// topLevelOrder is the order of top-level attributes in a formatted compose file
+var topLevelOrder = []string{"name", "include", "services", "networks", "volumes", "configs", "secrets"}
+
+// serviceOrder is the order of the most common service attributes in a formatted compose file. Other attributes
+// are sorted alphabetically after those
+var serviceOrder = []string{
+	"extends", "image", "build", "pull_policy", "platform", "profiles",
+	"container_name", "hostname", "domainname",
+	"command", "entrypoint", "working_dir", "user",
+	"environment", "env_file",
+	"ports", "expose", "volumes", "volumes_from", "tmpfs",
+	"networks", "network_mode", "links", "external_links", "extra_hosts", "dns", "dns_search", "dns_opt",
+	"depends_on", "healthcheck", "restart", "deploy", "scale",
+	"secrets", "configs", "labels", "annotations", "logging",
+}
+
+// sortedMappings are the attributes which are sorted by key in a formatted compose file
+var sortedMappings = []tree.Path{
+	"services.*.environment",
+	"services.*.labels",
+	"services.*.build.args",
+	"services.*.build.labels",
+	"networks.*.labels",
+	"volumes.*.labels",
+	"configs.*.labels",
+	"secrets.*.labels",
+}
+
+// Format rewrites the compose file in canonical layout, preserving comments:
+// - top-level attributes are ordered as name, include, services, networks, volumes, configs, secrets, then extensions
+// - service attributes are ordered consistently, extensions last
+// - attributes declaring an anchor are kept ahead of the aliases to it, and comments heading the file stay on top
+// - environment and labels are sorted
+// - ports which would be parsed as a base-60 number by a YAML 1.1 parser are quoted
+//
+// The whole compose file is then encoded by Bytes, with consistent indentation.
+func (e *Editor) Format() {
+	root := e.Root()
+	if len(root.Content) > 0 {
+		first := root.Content[0]
+		orderMapping(root, topLevelOrder, false)
+		if first != root.Content[0] {
+			// comment heading the first attribute is the compose file header, which stays on top
+			root.Content[0].HeadComment = joinComments(first.HeadComment, root.Content[0].HeadComment)
+			first.HeadComment = ""
+		}
+	}
+	if services := yamlnode.MappingValue(root, "services"); services != nil && services.Kind == yaml.MappingNode {
+		for i := 1; i < len(services.Content); i += 2 {
+			service := yamlnode.ResolveAlias(services.Content[i])
+			if service.Kind != yaml.MappingNode {
+				continue
+			}
+			orderMapping(service, serviceOrder, true)
+			if ports := yamlnode.MappingValue(service, "ports"); ports != nil {
+				quotePorts(yamlnode.ResolveAlias(ports))
+			}
+		}
+	}
+	sortAttributes(root, tree.NewPath())
+	e.modified = true
+	e.reformat = true
+}
+
+// orderMapping sorts mapping node keys by order, then other keys sorted alphabetically if sortOthers is set,
+// or keeping their relative order otherwise, then extensions. Entries declaring an anchor are kept ahead of the
+// entries using it as an alias.
+func orderMapping(node *yaml.Node, order []string, sortOthers bool) {
+	rank := func(key string) int {
+		if key == "<<" {
+			// merge key comes first, as values it sets are overridden by the mapping entries
+			return -1
+		}
+		for i, k := range order {
+			if k == key {
+				return i
+			}
+		}
+		if strings.HasPrefix(key, "x-") {
+			return len(order) + 1
+		}
+		return len(order)
+	}
+	pairs := mappingPairs(node)
+	sort.SliceStable(pairs, func(i, j int) bool {
+		ki, kj := pairs[i][0].Value, pairs[j][0].Value
+		ri, rj := rank(ki), rank(kj)
+		if ri != rj {
+			return ri < rj
+		}
+		if ri == len(order) && sortOthers {
+			return ki < kj
+		}
+		return false
+	})
+	setMappingPairs(node, anchorsFirst(pairs))
+}
+
+// anchorsFirst moves entries declaring an anchor before the first entry using it as an alias
+func anchorsFirst(pairs [][2]*yaml.Node) [][2]*yaml.Node {
+	type entry struct {
+		pair             [2]*yaml.Node
+		anchors, aliases map[*yaml.Node]bool
+	}
+	entries := make([]entry, len(pairs))
+	for i, pair := range pairs {
+		entries[i] = entry{pair: pair, anchors: map[*yaml.Node]bool{}, aliases: map[*yaml.Node]bool{}}
+		collectAnchors(pair[0], entries[i].anchors, entries[i].aliases)
+		collectAnchors(pair[1], entries[i].anchors, entries[i].aliases)
+	}
+	uses := func(user, declaring entry) bool {
+		for n := range user.aliases {
+			if declaring.anchors[n] {
+				return true
+			}
+		}
+		return false
+	}
+	// original order is valid, as an alias can't be used before its anchor, so this converges
+	for moved := true; moved; {
+		moved = false
+		for i := 1; i < len(entries) && !moved; i++ {
+			for j := 0; j < i; j++ {
+				if uses(entries[j], entries[i]) {
+					e := entries[i]
+					copy(entries[j+1:i+1], entries[j:i])
+					entries[j] = e
+					moved = true
+					break
+				}
+			}
+		}
+	}
+	for i, e := range entries {
+		pairs[i] = e.pair
+	}
+	return pairs
+}
+
+// collectAnchors records nodes declaring an anchor, and nodes referenced by aliases, in node tree
+func collectAnchors(node *yaml.Node, anchors, aliases map[*yaml.Node]bool) {
+	if node.Anchor != "" {
+		anchors[node] = true
+	}
+	if node.Kind == yaml.AliasNode {
+		aliases[node.Alias] = true
+		return
+	}
+	for _, c := range node.Content {
+		collectAnchors(c, anchors, aliases)
+	}
+}
+
+// sortAttributes sorts the mappings, and `KEY=VALUE` sequences, set by sortedMappings
+func sortAttributes(node *yaml.Node, path tree.Path) {
+	node = yamlnode.ResolveAlias(node)
+	for _, pattern := range sortedMappings {
+		if !path.Matches(pattern) {
+			continue
+		}
+		switch node.Kind {
+		case yaml.MappingNode:
+			pairs := mappingPairs(node)
+			sort.SliceStable(pairs, func(i, j int) bool {
+				return pairs[i][0].Value < pairs[j][0].Value
+			})
+			setMappingPairs(node, pairs)
+		case yaml.SequenceNode:
+			key := func(n *yaml.Node) string {
+				k, _, _ := strings.Cut(n.Value, "=")
+				return k
+			}
+			sort.SliceStable(node.Content, func(i, j int) bool {
+				return key(node.Content[i]) < key(node.Content[j])
+			})
+		}
+		return
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			sortAttributes(node.Content[i+1], path.Next(node.Content[i].Value))
+		}
+	}
+}
+
+// quotePorts sets double quotes on port strings a YAML 1.1 parser would read as a base-60 number
+func quotePorts(ports *yaml.Node) {
+	if ports.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, port := range ports.Content {
+		if port.Kind == yaml.ScalarNode && port.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) == 0 &&
+			utils.IsBase60(port.Value) {
+			port.Style = yaml.DoubleQuotedStyle
+			port.Tag = "!!str"
+		}
+	}
+}
+
+func mappingPairs(node *yaml.Node) [][2]*yaml.Node {
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	return pairs
+}
+
+func setMappingPairs(node *yaml.Node, pairs [][2]*yaml.Node) {
+	node.Content = node.Content[:0]
+	for _, pair := range pairs {
+		node.Content = append(node.Content, pair[0], pair[1])
+	}
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package editor
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+)
+
+func TestFormat(t *testing.T) {
+	e, err := Parse([]byte(`x-common: &common
+  restart: always
+volumes:
+  data: {}
+services:
+  web:
+    ports:
+      - 20:30
+      - "8080:80"
+    # web image
+    image: nginx
+    x-custom: true
+    environment:
+      ZOT: qix
+      FOO: bar # foo
+    stop_signal: SIGTERM
+    cap_add: [NET_ADMIN]
+    labels:
+      - com.example.b=2
+      - com.example.a=1
+name: myapp
+`))
+	assert.NilError(t, err)
+	e.Format()
+	content, err := e.Bytes()
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `name: myapp
+services:
+  web:
+    # web image
+    image: nginx
+    environment:
+      FOO: bar # foo
+      ZOT: qix
+    ports:
+      - "20:30"
+      - "8080:80"
+    labels:
+      - com.example.a=1
+      - com.example.b=2
+    cap_add: [NET_ADMIN]
+    stop_signal: SIGTERM
+    x-custom: true
+volumes:
+  data: {}
+x-common: &common
+  restart: always
+`)
+
+	// formatting is idempotent
+	formatted, err := Parse(content)
+	assert.NilError(t, err)
+	formatted.Format()
+	again, err := formatted.Bytes()
+	assert.NilError(t, err)
+	assert.Equal(t, string(again), string(content))
+}
+
+func TestFormatAnchors(t *testing.T) {
+	e, err := Parse([]byte(`# my application
+x-common: &common
+  restart: always
+services:
+  web:
+    <<: *common
+    image: nginx
+name: myapp
+`))
+	assert.NilError(t, err)
+	e.Format()
+	content, err := e.Bytes()
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `# my application
+name: myapp
+x-common: &common
+  restart: always
+services:
+  web:
+    <<: *common
+    image: nginx
+`)
+
+	var model map[string]any
+	assert.NilError(t, yaml.Unmarshal(content, &model))
+	assert.DeepEqual(t, model["services"], map[string]any{
+		"web": map[string]any{"image": "nginx", "restart": "always"},
+	})
+}
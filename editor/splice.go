@@ -0,0 +1,463 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package editor
+
+import (
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// nodeState is a snapshot of a yaml node as parsed, used to detect edited nodes
+type nodeState struct {
+	kind    yaml.Kind
+	style   yaml.Style
+	tag     string
+	value   string
+	anchor  string
+	head    string
+	line    string
+	foot    string
+	alias   *yaml.Node
+	content []*yaml.Node
+}
+
+func stateOf(node *yaml.Node) nodeState {
+	return nodeState{
+		kind:    node.Kind,
+		style:   node.Style,
+		tag:     node.Tag,
+		value:   node.Value,
+		anchor:  node.Anchor,
+		head:    node.HeadComment,
+		line:    node.LineComment,
+		foot:    node.FootComment,
+		alias:   node.Alias,
+		content: append([]*yaml.Node(nil), node.Content...),
+	}
+}
+
+// sameAttributes compares node states, ignoring content
+func (s nodeState) sameAttributes(other nodeState) bool {
+	return s.kind == other.kind && s.style == other.style && s.tag == other.tag && s.value == other.value &&
+		s.anchor == other.anchor && s.head == other.head && s.line == other.line && s.foot == other.foot &&
+		s.alias == other.alias
+}
+
+func (s nodeState) sameContent(other nodeState) bool {
+	if len(s.content) != len(other.content) {
+		return false
+	}
+	for i, n := range s.content {
+		if n != other.content[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// span is a range of lines, end excluded
+type span struct {
+	start, end int
+}
+
+// layout records parsed nodes and the lines they occupy in the original content, so that edited compose file can
+// be rendered by re-encoding only the edited entries
+type layout struct {
+	lines  []string
+	states map[*yaml.Node]nodeState
+	// spans are the lines occupied by a mapping entry, indexed by key, or by a sequence item, including head comment
+	spans map[*yaml.Node]span
+	// aligned are the block collections with each entry starting its own line, which edits can be spliced into
+	aligned map[*yaml.Node]bool
+	// footer is the first line of the document foot comment
+	footer int
+	root   *yaml.Node
+}
+
+func newLayout(content []byte, document *yaml.Node) *layout {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	l := &layout{
+		lines:   lines,
+		states:  map[*yaml.Node]nodeState{},
+		spans:   map[*yaml.Node]span{},
+		aligned: map[*yaml.Node]bool{},
+		footer:  len(lines),
+	}
+	last := len(lines) - 1
+	for last >= 0 && strings.TrimSpace(lines[last]) == "" {
+		last--
+	}
+	l.root = document.Content[0]
+	if comment := trailingComment(l.root); comment != nil && last >= 0 && strings.HasPrefix(lines[last], "#") {
+		// a comment ending the file is parsed as the foot comment of the last top-level key, unless separated by a
+		// blank line. Either way it is the document footer, which must stay last when entries are added
+		document.FootComment = joinComments(*comment, document.FootComment)
+		*comment = ""
+	}
+	l.record(document)
+	if document.FootComment != "" {
+		// footer starts with the first of the comment lines ending the file
+		l.footer = last + 1
+		for l.footer > 0 && (strings.TrimSpace(lines[l.footer-1]) == "" || strings.HasPrefix(lines[l.footer-1], "#")) {
+			l.footer--
+		}
+		for l.footer <= last && strings.TrimSpace(lines[l.footer]) == "" {
+			l.footer++
+		}
+	}
+	if l.root.Kind == yaml.MappingNode && l.root.Style&yaml.FlowStyle == 0 && len(l.root.Content) == 0 {
+		// empty compose file, new attributes are appended after comments
+		l.aligned[l.root] = true
+		return l
+	}
+	l.locate(l.root, 0, l.footer)
+	return l
+}
+
+func (l *layout) record(node *yaml.Node) {
+	if _, ok := l.states[node]; ok {
+		return
+	}
+	l.states[node] = stateOf(node)
+	for _, c := range node.Content {
+		l.record(c)
+	}
+}
+
+// locate computes the spans of block collection entries, within lines [start:end]
+func (l *layout) locate(node *yaml.Node, start, end int) {
+	if node.Style&yaml.FlowStyle != 0 || len(node.Content) == 0 {
+		return
+	}
+	entries := entryNodes(node)
+	if entries == nil {
+		return
+	}
+	starts := make([]int, len(entries))
+	for i, n := range entries {
+		if !l.startsLine(node, n) {
+			return
+		}
+		line := n.Line - 1
+		s := line - commentLines(n.HeadComment)
+		if i > 0 && s <= entries[i-1].Line-1 {
+			s = entries[i-1].Line
+		}
+		if s < start {
+			s = start
+		}
+		starts[i] = s
+	}
+	l.aligned[node] = true
+	for i, n := range entries {
+		e := end
+		if i+1 < len(entries) {
+			e = starts[i+1]
+		}
+		l.spans[n] = span{start: starts[i], end: e}
+		value := n
+		if node.Kind == yaml.MappingNode {
+			value = node.Content[2*i+1]
+		}
+		l.locate(value, starts[i], e)
+	}
+}
+
+// startsLine checks entry of a block collection is the first token on its line
+func (l *layout) startsLine(node *yaml.Node, entry *yaml.Node) bool {
+	if entry.Line < 1 || entry.Line > len(l.lines) {
+		return false
+	}
+	text := l.lines[entry.Line-1]
+	indent := len(text) - len(strings.TrimLeft(text, " "))
+	if indent != node.Column-1 {
+		return false
+	}
+	if node.Kind == yaml.SequenceNode {
+		return strings.HasPrefix(text[indent:], "-")
+	}
+	return entry.Column == node.Column
+}
+
+// entryNodes returns the nodes identifying entries in a collection: keys for a mapping, items for a sequence
+func entryNodes(node *yaml.Node) []*yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		keys := make([]*yaml.Node, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keys = append(keys, node.Content[i])
+		}
+		return keys
+	case yaml.SequenceNode:
+		return node.Content
+	}
+	return nil
+}
+
+// trailingComment returns the foot comment of the last key in a block mapping, if any
+func trailingComment(node *yaml.Node) *string {
+	if node.Kind != yaml.MappingNode || node.Style&yaml.FlowStyle != 0 || len(node.Content) < 2 {
+		return nil
+	}
+	key := node.Content[len(node.Content)-2]
+	if key.FootComment == "" {
+		return nil
+	}
+	return &key.FootComment
+}
+
+func joinComments(comments ...string) string {
+	var lines []string
+	for _, c := range comments {
+		if c != "" {
+			lines = append(lines, c)
+		}
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+func commentLines(comment string) int {
+	if comment == "" {
+		return 0
+	}
+	return strings.Count(comment, "\n") + 1
+}
+
+// splicer renders an edited document, copying original lines for entries which have not been edited
+type splicer struct {
+	layout *layout
+	buf    bytes.Buffer
+	clean  map[*yaml.Node]bool
+	// blank are the blank lines after the last entry rendered, written before the next original entry
+	blank []string
+}
+
+// splice renders document, or returns false if edits can't be spliced into original content
+func (l *layout) splice(document *yaml.Node, indent int) ([]byte, bool, error) {
+	state, ok := l.states[document]
+	root := document.Content[0]
+	if !ok || !state.sameAttributes(stateOf(document)) || len(document.Content) != 1 || state.content[0] != root {
+		return nil, false, nil
+	}
+	s := &splicer{layout: l, clean: map[*yaml.Node]bool{}}
+	if !s.spliceable(root) {
+		return nil, false, nil
+	}
+	header := l.footer
+	if original := entryNodes(&yaml.Node{Kind: root.Kind, Content: l.states[root].content}); len(original) > 0 {
+		header = l.spans[original[0]].start
+	}
+	s.write(0, header)
+	if err := s.collection(root, indent); err != nil {
+		return nil, false, err
+	}
+	s.flush()
+	s.write(l.footer, len(l.lines))
+	return s.buf.Bytes(), true, nil
+}
+
+// spliceable checks node is an original block collection, with unchanged attributes, which entries can be
+// rendered independently
+func (s *splicer) spliceable(node *yaml.Node) bool {
+	state, ok := s.layout.states[node]
+	if !ok || !s.layout.aligned[node] || !state.sameAttributes(stateOf(node)) {
+		return false
+	}
+	// a nested collection without entries has to be encoded as flow, i.e. `{}` or `[]`
+	return len(node.Content) > 0 || node == s.layout.root
+}
+
+// collection renders entries of node, using unit as indentation for the nested levels of encoded entries
+func (s *splicer) collection(node *yaml.Node, unit int) error {
+	indent := 0
+	if node != s.layout.root || len(s.layout.states[node].content) > 0 {
+		indent = node.Column - 1
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := s.entry(node, node.Content[i], node.Content[i+1], indent, unit); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, item := range node.Content {
+		if err := s.entry(node, nil, item, indent, unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entry renders a mapping entry, or a sequence item if key is nil
+func (s *splicer) entry(parent, key, value *yaml.Node, indent, unit int) error {
+	id := key
+	if key == nil {
+		id = value
+	}
+	sp, ok := s.layout.spans[id]
+	if !ok || !s.owns(parent, id) {
+		// new entries are added before the blank lines separating the previous entry from the next one
+		return s.encode(key, value, indent, unit)
+	}
+	s.flush()
+	// blank lines separating entry from the next one are kept
+	end := sp.end
+	for end-1 > sp.start && strings.TrimSpace(s.layout.lines[end-1]) == "" {
+		end--
+	}
+	defer func() {
+		s.blank = s.layout.lines[end:sp.end]
+	}()
+	if s.isClean(key) && s.isClean(value) {
+		s.write(sp.start, end)
+		return nil
+	}
+	if key != nil && s.isClean(key) && s.spliceable(value) {
+		original := entryNodes(&yaml.Node{Kind: value.Kind, Content: s.layout.states[value].content})
+		s.write(sp.start, s.layout.spans[original[0]].start)
+		if u := value.Column - 1 - indent; u > 0 {
+			unit = u
+		}
+		return s.collection(value, unit)
+	}
+	return s.encode(key, value, indent, unit)
+}
+
+// owns checks entry was declared by parent in original content
+func (s *splicer) owns(parent, entry *yaml.Node) bool {
+	for _, n := range s.layout.states[parent].content {
+		if n == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// isClean checks node and its children have not been edited
+func (s *splicer) isClean(node *yaml.Node) bool {
+	if node == nil {
+		return true
+	}
+	if clean, ok := s.clean[node]; ok {
+		return clean
+	}
+	state, ok := s.layout.states[node]
+	current := stateOf(node)
+	clean := ok && state.sameAttributes(current) && state.sameContent(current)
+	if clean && node.Kind != yaml.AliasNode {
+		for _, c := range node.Content {
+			if !s.isClean(c) {
+				clean = false
+				break
+			}
+		}
+	}
+	s.clean[node] = clean
+	return clean
+}
+
+// write copies original lines [start:end]
+func (s *splicer) write(start, end int) {
+	for _, line := range s.layout.lines[start:end] {
+		s.newLine()
+		s.buf.WriteString(line)
+	}
+}
+
+// flush writes pending blank lines
+func (s *splicer) flush() {
+	for _, line := range s.blank {
+		s.newLine()
+		s.buf.WriteString(line)
+	}
+	s.blank = nil
+}
+
+// encode renders a mapping entry, or a sequence item if key is nil, at indent, using unit for nested levels
+func (s *splicer) encode(key, value *yaml.Node, indent, unit int) error {
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{value}}
+	if key != nil {
+		node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{key, value}}
+	}
+	b, err := encodeNode(node, unit)
+	if err != nil {
+		return err
+	}
+	s.newLine()
+	prefix := strings.Repeat(" ", indent)
+	for _, line := range strings.SplitAfter(string(b), "\n") {
+		if line == "" {
+			continue
+		}
+		if line != "\n" {
+			s.buf.WriteString(prefix)
+		}
+		s.buf.WriteString(line)
+	}
+	return nil
+}
+
+// newLine terminates the last line written, if original content has no final line break
+func (s *splicer) newLine() {
+	if b := s.buf.Bytes(); len(b) > 0 && b[len(b)-1] != '\n' {
+		s.buf.WriteByte('\n')
+	}
+}
+
+func encodeNode(node *yaml.Node, indent int) ([]byte, error) {
+	// yaml.v3 encodes merge keys as `!!merge <<`, which is valid but unexpected in a compose file
+	merge := mergeKeys(node, nil)
+	for _, key := range merge {
+		key.Tag = ""
+	}
+	defer func() {
+		for _, key := range merge {
+			key.Tag = "!!merge"
+		}
+	}()
+	buf := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(indent)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeKeys collects the `<<` merge keys in node tree
+func mergeKeys(node *yaml.Node, keys []*yaml.Node) []*yaml.Node {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Tag == "!!merge" {
+				keys = append(keys, node.Content[i])
+			}
+		}
+	}
+	for _, c := range node.Content {
+		keys = mergeKeys(c, keys)
+	}
+	return keys
+}
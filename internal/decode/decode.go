@@ -0,0 +1,169 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package decode implements the mapstructure decode hooks used to convert compose model into types
+package decode
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/diagnostics"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Decoder is comparable to yaml.Unmarshaler, allowing a type to define it's own custom logic to convert value
+// see https://github.com/mitchellh/mapstructure/pull/294
+type Decoder interface {
+	DecodeMapstructure(interface{}) error
+}
+
+// Decode converts source into target, using yaml tags as attribute names
+func Decode(source interface{}, target interface{}) error {
+	config := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(Hook, Cast),
+		Result:     target,
+		TagName:    "yaml",
+	}
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(source)
+}
+
+// Hook invokes DecodeMapstructure for types implementing Decoder
+// see https://github.com/mitchellh/mapstructure/issues/115#issuecomment-735287466
+// adapted to support types derived from built-in types, as DecodeMapstructure would not be able to mutate internal
+// value, so need to invoke DecodeMapstructure defined by pointer to type
+func Hook(from reflect.Value, to reflect.Value) (interface{}, error) {
+	// If the destination implements the decoder interface
+	u, ok := to.Interface().(Decoder)
+	if !ok {
+		// for non-struct types we need to invoke func (*type) DecodeMapstructure()
+		if to.CanAddr() {
+			pto := to.Addr()
+			u, ok = pto.Interface().(Decoder)
+		}
+		if !ok {
+			return from.Interface(), nil
+		}
+	}
+	// If it is nil and a pointer, create and assign the target value first
+	if to.Type().Kind() == reflect.Ptr && to.IsNil() {
+		to.Set(reflect.New(to.Type().Elem()))
+		u = to.Interface().(Decoder)
+	}
+	// Call the custom DecodeMapstructure method
+	if err := u.DecodeMapstructure(from.Interface()); err != nil {
+		return to.Interface(), err
+	}
+	return to.Interface(), nil
+}
+
+// Cast converts strings, as set by interpolation, into the target scalar type. YAML 1.1 booleans are reported to
+// diagnostics.Logrus
+func Cast(from reflect.Value, to reflect.Value) (interface{}, error) {
+	return CastWithDiagnostics(diagnostics.Logrus)(from, to)
+}
+
+// CastWithDiagnostics returns a Cast hook which reports YAML 1.1 booleans to sink
+func CastWithDiagnostics(sink diagnostics.Sink) func(from reflect.Value, to reflect.Value) (interface{}, error) {
+	return func(from reflect.Value, to reflect.Value) (interface{}, error) {
+		if from.Type().Kind() == reflect.String && to.Kind() == reflect.Bool {
+			if d, ok := YAML11Boolean(from.String()); ok {
+				sink.Report(d)
+			}
+		}
+		return cast(from, to)
+	}
+}
+
+func cast(from reflect.Value, to reflect.Value) (interface{}, error) {
+	switch from.Type().Kind() {
+	case reflect.String:
+		switch to.Kind() {
+		case reflect.Bool:
+			return ToBoolean(from.String())
+		case reflect.Int:
+			return ToInt(from.String())
+		case reflect.Int64:
+			return ToInt64(from.String())
+		case reflect.Float32:
+			return ToFloat32(from.String())
+		case reflect.Float64:
+			return ToFloat(from.String())
+		}
+	case reflect.Int:
+		if to.Kind() == reflect.String {
+			return strconv.FormatInt(from.Int(), 10), nil
+		}
+	}
+	return from.Interface(), nil
+}
+
+func ToInt(value string) (interface{}, error) {
+	return strconv.Atoi(value)
+}
+
+func ToInt64(value string) (interface{}, error) {
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func ToFloat(value string) (interface{}, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+func ToFloat32(value string) (interface{}, error) {
+	f, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return nil, err
+	}
+	return float32(f), nil
+}
+
+// ToBoolean should match http://yaml.org/type/bool.html. YAML 1.1 values are accepted, callers can use
+// YAML11Boolean to report them
+func ToBoolean(value string) (interface{}, error) {
+	switch strings.ToLower(value) {
+	case "true", "y", "yes", "on":
+		return true, nil
+	case "false", "n", "no", "off":
+		return false, nil
+	default:
+		return nil, errors.Errorf("invalid boolean: %s", value)
+	}
+}
+
+// YAML11Boolean returns a diagnostic if value is a YAML 1.1 boolean, which is not supported by YAML 1.2
+func YAML11Boolean(value string) (diagnostics.Diagnostic, bool) {
+	var b bool
+	switch strings.ToLower(value) {
+	case "y", "yes", "on":
+		b = true
+	case "n", "no", "off":
+		b = false
+	default:
+		return diagnostics.Diagnostic{}, false
+	}
+	return diagnostics.Diagnostic{
+		Code:    diagnostics.Deprecated,
+		Message: fmt.Sprintf("%q for boolean is not supported by YAML 1.2, please use `%t`", value, b),
+	}, true
+}
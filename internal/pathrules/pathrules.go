@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package pathrules selects values registered by path pattern, for the most specific pattern matching a path
+package pathrules
+
+import (
+	"github.com/compose-spec/compose-go/v2/tree"
+)
+
+// rule is a value registered for paths matching pattern
+type rule[T any] struct {
+	pattern tree.Path
+	value   T
+}
+
+// Rules are values registered by path pattern, in registration order. Patterns use `*` to match any key, and `[]`
+// to match list items. The zero value is ready to use. Rules are not safe for concurrent use, so registration is
+// designed to happen during program initialization.
+type Rules[T any] struct {
+	rules []rule[T]
+}
+
+// Register sets value for pattern, replacing the value previously registered for the same pattern
+func (r *Rules[T]) Register(pattern tree.Path, value T) {
+	for i, existing := range r.rules {
+		if existing.pattern == pattern {
+			r.rules[i].value = value
+			return
+		}
+	}
+	r.rules = append(r.rules, rule[T]{pattern: pattern, value: value})
+}
+
+// Match returns the value registered for the most specific pattern matching p, i.e. the one with the least
+// wildcards. Between equally specific patterns, the last registered one wins.
+func (r *Rules[T]) Match(p tree.Path) (T, bool) {
+	var (
+		value T
+		found bool
+		best  int
+	)
+	for _, rule := range r.rules {
+		if !p.Matches(rule.pattern) {
+			continue
+		}
+		if s := specificity(rule.pattern); !found || s >= best {
+			value, found, best = rule.value, true, s
+		}
+	}
+	return value, found
+}
+
+// Len returns the number of registered patterns
+func (r *Rules[T]) Len() int {
+	return len(r.rules)
+}
+
+// Clone returns a copy of r, so that registrations can later be restored
+func (r *Rules[T]) Clone() Rules[T] {
+	return Rules[T]{rules: append([]rule[T](nil), r.rules...)}
+}
+
+// specificity counts the parts of pattern which are not wildcards
+func specificity(pattern tree.Path) int {
+	n := 0
+	for _, part := range pattern.Parts() {
+		if part != tree.PathMatchAll && part != tree.PathMatchList {
+			n++
+		}
+	}
+	return n
+}
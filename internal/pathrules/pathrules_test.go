@@ -0,0 +1,60 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pathrules
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+	"gotest.tools/v3/assert"
+)
+
+func TestMatch(t *testing.T) {
+	var rules Rules[string]
+	rules.Register("services.web.x-foo", "specific")
+	rules.Register("services.*.x-foo", "generic")
+	rules.Register("*.*.x-foo", "any")
+	rules.Register("services.*.x-bar", "first")
+	rules.Register("*.web.x-bar", "last")
+	rules.Register("services.*.[].x-baz", "item")
+
+	tests := map[string]string{
+		"services.web.x-foo":      "specific",
+		"services.db.x-foo":       "generic",
+		"volumes.data.x-foo":      "any",
+		"services.web.x-bar":      "last",
+		"services.db.x-bar":       "first",
+		"services.web.[].x-baz":   "item",
+		"services.web.x-unknown":  "",
+		"services.web.sub.x-foo":  "",
+		"services.web.0.x-baz":    "",
+		"services.web.x-foo.x-io": "",
+	}
+	for path, expected := range tests {
+		value, ok := rules.Match(tree.Path(path))
+		assert.Equal(t, value, expected, path)
+		assert.Equal(t, ok, expected != "", path)
+	}
+
+	clone := rules.Clone()
+	rules.Register("services.*.x-foo", "replaced")
+	value, _ := rules.Match("services.db.x-foo")
+	assert.Equal(t, value, "replaced")
+	value, _ = clone.Match("services.db.x-foo")
+	assert.Equal(t, value, "generic")
+	assert.Equal(t, rules.Len(), 6)
+}
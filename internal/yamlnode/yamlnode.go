@@ -0,0 +1,94 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package yamlnode provides helpers to navigate and edit a yaml.Node tree
+package yamlnode
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// KeyNode creates a mapping key node
+func KeyNode(key string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+}
+
+// MappingIndex returns the index of key in mapping node content, or -1 if not found
+func MappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// MappingValue returns the value for key in mapping node, including values set by a `<<` merge key
+func MappingValue(node *yaml.Node, key string) *yaml.Node {
+	if i := MappingIndex(node, key); i >= 0 {
+		return node.Content[i+1]
+	}
+	i := MappingIndex(node, "<<")
+	if i < 0 {
+		return nil
+	}
+	merged := ResolveAlias(node.Content[i+1])
+	sources := []*yaml.Node{merged}
+	if merged.Kind == yaml.SequenceNode {
+		sources = merged.Content
+	}
+	for _, source := range sources {
+		if source = ResolveAlias(source); source.Kind == yaml.MappingNode {
+			if value := MappingValue(source, key); value != nil {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveAlias returns the node anchored by an alias, or node itself
+func ResolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// Unalias returns parent's child at index i, replacing an alias by a copy of the anchored node
+func Unalias(parent *yaml.Node, i int) *yaml.Node {
+	child := parent.Content[i]
+	if child.Kind != yaml.AliasNode {
+		return child
+	}
+	cp := DeepCopy(ResolveAlias(child))
+	cp.Anchor = ""
+	cp.HeadComment = child.HeadComment
+	cp.LineComment = child.LineComment
+	cp.FootComment = child.FootComment
+	parent.Content[i] = cp
+	return cp
+}
+
+// DeepCopy copies node and its children
+func DeepCopy(node *yaml.Node) *yaml.Node {
+	cp := *node
+	cp.Content = make([]*yaml.Node, len(node.Content))
+	for i, c := range node.Content {
+		cp.Content[i] = DeepCopy(c)
+	}
+	return &cp
+}
@@ -17,8 +17,11 @@
 package interpolation
 
 import (
+	"fmt"
 	"os"
 
+	"github.com/compose-spec/compose-go/v2/diagnostics"
+	"github.com/compose-spec/compose-go/v2/internal/decode"
 	"github.com/compose-spec/compose-go/v2/template"
 	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/pkg/errors"
@@ -30,8 +33,11 @@ type Options struct {
 	LookupValue LookupValue
 	// TypeCastMapping maps key paths to functions to cast to a type
 	TypeCastMapping map[tree.Path]Cast
-	// Substitution function to use
+	// Substitution function to use. If not set, variables which are not set are reported to Diagnostics
 	Substitute func(string, template.Mapping) (string, error)
+	// Diagnostics receives warnings about variables not being set and YAML 1.1 booleans, defaults to
+	// diagnostics.Logrus
+	Diagnostics diagnostics.Sink
 }
 
 // LookupValue is a function which maps from variable names to values.
@@ -51,8 +57,8 @@ func Interpolate(config map[string]interface{}, opts Options) (map[string]interf
 	if opts.TypeCastMapping == nil {
 		opts.TypeCastMapping = make(map[tree.Path]Cast)
 	}
-	if opts.Substitute == nil {
-		opts.Substitute = template.Substitute
+	if opts.Diagnostics == nil {
+		opts.Diagnostics = diagnostics.Logrus
 	}
 
 	out := map[string]interface{}{}
@@ -71,7 +77,7 @@ func Interpolate(config map[string]interface{}, opts Options) (map[string]interf
 func recursiveInterpolate(value interface{}, path tree.Path, opts Options) (interface{}, error) {
 	switch value := value.(type) {
 	case string:
-		newValue, err := opts.Substitute(value, template.Mapping(opts.LookupValue))
+		newValue, err := opts.substitute(value, path)
 		if err != nil {
 			return value, newPathError(path, err)
 		}
@@ -80,6 +86,12 @@ func recursiveInterpolate(value interface{}, path tree.Path, opts Options) (inte
 			return newValue, nil
 		}
 		casted, err := caster(newValue)
+		if _, ok := casted.(bool); ok {
+			if d, ok := decode.YAML11Boolean(newValue); ok {
+				d.Path = path
+				opts.Diagnostics.Report(d)
+			}
+		}
 		return casted, newPathError(path, errors.Wrap(err, "failed to cast to expected type"))
 
 	case map[string]interface{}:
@@ -109,6 +121,21 @@ func recursiveInterpolate(value interface{}, path tree.Path, opts Options) (inte
 	}
 }
 
+// substitute replaces variables in value set at path
+func (o Options) substitute(value string, path tree.Path) (string, error) {
+	if o.Substitute != nil {
+		return o.Substitute(value, template.Mapping(o.LookupValue))
+	}
+	return template.SubstituteWithOptions(value, template.Mapping(o.LookupValue),
+		template.WithUnsetVariableFunction(func(variable string) {
+			o.Diagnostics.Report(diagnostics.Diagnostic{
+				Code:    diagnostics.VariableNotSet,
+				Message: fmt.Sprintf("The %q variable is not set. Defaulting to a blank string.", variable),
+				Path:    path,
+			})
+		}))
+}
+
 func newPathError(path tree.Path, err error) error {
 	switch err := err.(type) {
 	case nil:
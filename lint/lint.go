@@ -0,0 +1,172 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package lint checks compose models against best practices
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// IgnoreExtension is the extension used to suppress findings by rule name. It can be set at the top-level of the
+// compose file or on any resource, and applies to findings about that element.
+const IgnoreExtension = "x-lint-ignore"
+
+// Severity of a Finding
+type Severity int
+
+// Severity levels, by increasing importance
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// ParseSeverity parses a severity name
+func ParseSeverity(s string) (Severity, error) {
+	for _, severity := range []Severity{Info, Warning, Error} {
+		if strings.EqualFold(s, severity.String()) {
+			return severity, nil
+		}
+	}
+	return 0, errors.Errorf("invalid severity %q, expected one of info, warning, error", s)
+}
+
+// Finding reports an issue detected by a Rule
+type Finding struct {
+	// Rule is the name of the rule which detected the issue
+	Rule string
+	// Severity of the issue
+	Severity Severity
+	// Path is the compose model element the issue relates to
+	Path tree.Path
+	// Message describes the issue
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Path, f.Severity, f.Message, f.Rule)
+}
+
+// Rule checks a compose model against a best practice
+type Rule interface {
+	// Name identifies the rule, and is used by `x-lint-ignore` to suppress its findings
+	Name() string
+	// Check returns the issues detected in compose model, available both as the raw yaml model and as the loaded
+	// project
+	Check(dict map[string]any, project *types.Project) []Finding
+}
+
+var rules = append([]Rule(nil), builtins...)
+
+// Register adds rule to those checked by Lint
+func Register(rule Rule) {
+	rules = append(rules, rule)
+}
+
+// Rules returns the registered rules
+func Rules() []Rule {
+	return append([]Rule(nil), rules...)
+}
+
+// Lint checks compose model against registered rules, or the ones passed as parameters, and returns findings sorted
+// by path. Raw dict is used to lookup `x-lint-ignore`, and computed from project if nil.
+func Lint(dict map[string]any, project *types.Project, only ...Rule) ([]Finding, error) {
+	if dict == nil {
+		var err error
+		if dict, err = toDict(project); err != nil {
+			return nil, err
+		}
+	}
+	if len(only) == 0 {
+		only = rules
+	}
+	var findings []Finding
+	for _, rule := range only {
+		for _, f := range rule.Check(dict, project) {
+			f.Rule = rule.Name()
+			if !ignored(dict, f) {
+				findings = append(findings, f)
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Path < findings[j].Path
+	})
+	return findings, nil
+}
+
+// ignored checks x-lint-ignore declared by elements along finding's path
+func ignored(dict map[string]any, f Finding) bool {
+	node := dict
+	for _, part := range f.Path.Parts() {
+		if ignores(node, f.Rule) {
+			return true
+		}
+		next, ok := node[part].(map[string]any)
+		if !ok {
+			return false
+		}
+		node = next
+	}
+	return ignores(node, f.Rule)
+}
+
+// ignores checks x-lint-ignore declared by node, as a single rule name or a list, includes rule
+func ignores(node map[string]any, rule string) bool {
+	switch ignore := node[IgnoreExtension].(type) {
+	case string:
+		return ignore == rule
+	case []any:
+		for _, r := range ignore {
+			if r == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toDict computes the raw model for a project
+func toDict(project *types.Project) (map[string]any, error) {
+	b, err := project.MarshalYAML()
+	if err != nil {
+		return nil, err
+	}
+	var dict map[string]any
+	err = yaml.Unmarshal(b, &dict)
+	return dict, err
+}
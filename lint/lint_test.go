@@ -0,0 +1,146 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lint
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func load(t *testing.T, yaml string) *types.Project {
+	t.Helper()
+	project, err := loader.LoadWithContext(context.TODO(), types.ConfigDetails{
+		ConfigFiles: []types.ConfigFile{{Filename: "compose.yaml", Content: []byte(yaml)}},
+		Environment: map[string]string{},
+	}, func(options *loader.Options) {
+		options.SetProjectName("lint", true)
+		options.ResolvePaths = false
+	})
+	assert.NilError(t, err)
+	return project
+}
+
+func lint(t *testing.T, project *types.Project) []string {
+	t.Helper()
+	findings, err := Lint(nil, project)
+	assert.NilError(t, err)
+	var actual []string
+	for _, f := range findings {
+		actual = append(actual, f.String())
+	}
+	return actual
+}
+
+func TestLint(t *testing.T) {
+	project := load(t, `
+services:
+  app:
+    image: nginx
+    privileged: true
+    network_mode: host
+    environment:
+      DB_PASSWORD: s3cr3t
+      DB_PASSWORD_FILE: /run/secrets/db
+      DB_USER: app
+    volumes:
+      - /var/run/docker.sock:/var/run/docker.sock
+    depends_on:
+      db:
+        condition: service_healthy
+  db:
+    image: postgres:latest
+  web:
+    image: nginx@sha256:0000000000000000000000000000000000000000000000000000000000000000
+    build: .
+`)
+	assert.DeepEqual(t, lint(t, project), []string{
+		"services.app.environment.DB_PASSWORD: warning: variable looks like a secret, use `secrets` so that it is not exposed by container inspection (environment-secret)",
+		"services.app.image: warning: image has no tag, and will resolve to `latest`, pin a version to get reproducible deployments (image-tag)",
+		"services.app.network_mode: warning: service shares the host network stack, and is not isolated from host network (network-mode-host)",
+		"services.app.privileged: error: service runs with all host capabilities, prefer adding only the required ones with cap_add (privileged)",
+		"services.app.volumes: error: /var/run/docker.sock is bind mounted, which grants service full control of the host (docker-socket)",
+		"services.db.healthcheck: warning: app wait(s) for service to be healthy, but no healthcheck is defined, unless set by image (healthcheck-required)",
+		"services.db.image: warning: image uses the `latest` tag, pin a version to get reproducible deployments (image-tag)",
+	})
+}
+
+func TestLintIgnore(t *testing.T) {
+	project := load(t, `
+x-lint-ignore: network-mode-host
+services:
+  app:
+    image: nginx:1.25
+    privileged: true
+    network_mode: host
+    x-lint-ignore: [privileged]
+  other:
+    image: nginx:1.25
+    privileged: true
+`)
+	assert.DeepEqual(t, lint(t, project), []string{
+		"services.other.privileged: error: service runs with all host capabilities, prefer adding only the required ones with cap_add (privileged)",
+	})
+}
+
+type customRule struct{}
+
+func (customRule) Name() string {
+	return "no-restart"
+}
+
+func (customRule) Check(dict map[string]any, _ *types.Project) []Finding {
+	var findings []Finding
+	for name, service := range dict["services"].(map[string]any) {
+		if _, ok := service.(map[string]any)["restart"]; !ok {
+			findings = append(findings, Finding{Severity: Info, Path: tree.NewPath("services", name), Message: "restart policy is not set"})
+		}
+	}
+	return findings
+}
+
+func TestLintCustomRule(t *testing.T) {
+	project := load(t, `
+services:
+  app:
+    image: nginx:1.25
+`)
+	findings, err := Lint(map[string]any{
+		"services": map[string]any{
+			"app": map[string]any{"image": "nginx:1.25"},
+		},
+	}, project, customRule{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, findings, []Finding{{
+		Rule:     "no-restart",
+		Severity: Info,
+		Path:     "services.app",
+		Message:  "restart policy is not set",
+	}})
+}
+
+func TestParseSeverity(t *testing.T) {
+	s, err := ParseSeverity("Warning")
+	assert.NilError(t, err)
+	assert.Equal(t, s, Warning)
+	_, err = ParseSeverity("fatal")
+	assert.ErrorContains(t, err, `invalid severity "fatal"`)
+}
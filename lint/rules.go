@@ -0,0 +1,172 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/distribution/reference"
+)
+
+// builtins are the rules registered by default. Rules granting services control of the host report errors, others
+// report warnings
+var builtins = []Rule{
+	serviceRule{"privileged", checkPrivileged},
+	serviceRule{"image-tag", checkImageTag},
+	serviceRule{"network-mode-host", checkNetworkModeHost},
+	serviceRule{"healthcheck-required", checkHealthcheckRequired},
+	serviceRule{"environment-secret", checkEnvironmentSecret},
+	serviceRule{"docker-socket", checkDockerSocket},
+}
+
+// serviceRule is a Rule checking services one by one
+type serviceRule struct {
+	name  string
+	check func(project *types.Project, service types.ServiceConfig) []Finding
+}
+
+func (r serviceRule) Name() string {
+	return r.name
+}
+
+func (r serviceRule) Check(_ map[string]any, project *types.Project) []Finding {
+	names := project.ServiceNames()
+	sort.Strings(names)
+	var findings []Finding
+	for _, name := range names {
+		findings = append(findings, r.check(project, project.Services[name])...)
+	}
+	return findings
+}
+
+func checkPrivileged(_ *types.Project, service types.ServiceConfig) []Finding {
+	if !service.Privileged {
+		return nil
+	}
+	return []Finding{{
+		Severity: Error,
+		Path:     tree.NewPath("services", service.Name, "privileged"),
+		Message:  "service runs with all host capabilities, prefer adding only the required ones with cap_add",
+	}}
+}
+
+func checkImageTag(_ *types.Project, service types.ServiceConfig) []Finding {
+	if service.Image == "" || service.Build != nil {
+		// image is the name set on the image being built
+		return nil
+	}
+	named, err := reference.ParseNormalizedNamed(service.Image)
+	if err != nil {
+		return nil
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return nil
+	}
+	message := "image has no tag, and will resolve to `latest`"
+	if tagged, ok := named.(reference.Tagged); ok {
+		if tagged.Tag() != "latest" {
+			return nil
+		}
+		message = "image uses the `latest` tag"
+	}
+	return []Finding{{
+		Severity: Warning,
+		Path:     tree.NewPath("services", service.Name, "image"),
+		Message:  message + ", pin a version to get reproducible deployments",
+	}}
+}
+
+func checkNetworkModeHost(_ *types.Project, service types.ServiceConfig) []Finding {
+	if service.NetworkMode != "host" {
+		return nil
+	}
+	return []Finding{{
+		Severity: Warning,
+		Path:     tree.NewPath("services", service.Name, "network_mode"),
+		Message:  "service shares the host network stack, and is not isolated from host network",
+	}}
+}
+
+func checkHealthcheckRequired(project *types.Project, service types.ServiceConfig) []Finding {
+	if service.HealthCheck != nil && !service.HealthCheck.Disable {
+		return nil
+	}
+	var dependents []string
+	for _, s := range project.Services {
+		if d, ok := s.DependsOn[service.Name]; ok && d.Condition == types.ServiceConditionHealthy {
+			dependents = append(dependents, s.Name)
+		}
+	}
+	if len(dependents) == 0 {
+		return nil
+	}
+	sort.Strings(dependents)
+	return []Finding{{
+		Severity: Warning,
+		Path:     tree.NewPath("services", service.Name, "healthcheck"),
+		Message: fmt.Sprintf("%s wait(s) for service to be healthy, but no healthcheck is defined, unless set by image",
+			strings.Join(dependents, ", ")),
+	}}
+}
+
+// secretVariable matches environment variables names commonly used to pass sensitive data
+var secretVariable = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|PRIVATE_?KEY|CREDENTIALS?)`)
+
+func checkEnvironmentSecret(_ *types.Project, service types.ServiceConfig) []Finding {
+	var findings []Finding
+	for name, value := range service.Environment {
+		if value == nil || *value == "" || strings.HasSuffix(strings.ToUpper(name), "_FILE") {
+			// _FILE suffix is the common convention to pass the path to a secret file
+			continue
+		}
+		if secretVariable.MatchString(name) {
+			findings = append(findings, Finding{
+				Severity: Warning,
+				Path:     tree.NewPath("services", service.Name, "environment", name),
+				Message:  "variable looks like a secret, use `secrets` so that it is not exposed by container inspection",
+			})
+		}
+	}
+	return findings
+}
+
+// dockerSockets are the well-known paths of the docker engine API socket
+var dockerSockets = []string{"/var/run/docker.sock", "/run/docker.sock"}
+
+func checkDockerSocket(_ *types.Project, service types.ServiceConfig) []Finding {
+	var findings []Finding
+	for _, volume := range service.Volumes {
+		if volume.Type != types.VolumeTypeBind {
+			continue
+		}
+		for _, socket := range dockerSockets {
+			if volume.Source == socket {
+				findings = append(findings, Finding{
+					Severity: Error,
+					Path:     tree.NewPath("services", service.Name, "volumes"),
+					Message:  fmt.Sprintf("%s is bind mounted, which grants service full control of the host", socket),
+				})
+			}
+		}
+	}
+	return findings
+}
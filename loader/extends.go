@@ -64,6 +64,7 @@ func ApplyExtends(ctx context.Context, dict map[string]any, workingdir string, o
 				if err != nil {
 					return err
 				}
+				opts.processEvent(EventExtends, map[string]any{"service": name, "file": local})
 				relworkingdir := filepath.Dir(local)
 				if !filepath.IsAbs(local) {
 					relworkingdir, err = filepath.Rel(workingdir, relworkingdir)
@@ -102,11 +103,14 @@ func ApplyExtends(ctx context.Context, dict map[string]any, workingdir string, o
 		}
 		source := deepClone(base).(map[string]any)
 		for _, processor := range post {
-			processor.Apply(map[string]any{
+			err := processor.Apply(map[string]any{
 				"services": map[string]any{
 					name: source,
 				},
 			})
+			if err != nil {
+				return err
+			}
 		}
 		merged, err := override.ExtendService(source, service)
 		if err != nil {
@@ -0,0 +1,88 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/consts"
+	"github.com/compose-spec/compose-go/v2/internal/pathrules"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/utils"
+)
+
+// extensionTypes are the Go types registered to decode extensions, by path pattern
+var extensionTypes pathrules.Rules[reflect.Type]
+
+// RegisterExtension registers the Go type an extension is decoded to, set by sample, a value of this type. Extension
+// is set by a path pattern, using `*` to match any key and `[]` to match list items, like `services.*.x-traefik`,
+// as for schema.RegisterExtension. Loaded project then exposes extension as a value of this type. When patterns
+// overlap, the type registered with the most specific pattern applies.
+// RegisterExtension is designed to be called during program initialization, and is not safe for concurrent use.
+func RegisterExtension(path string, sample any) error {
+	pattern := tree.Path(path)
+	if !strings.HasPrefix(pattern.Last(), "x-") {
+		return fmt.Errorf("invalid extension path %q, must end with an x- attribute", path)
+	}
+	if sample == nil {
+		return fmt.Errorf("extension %s: a sample value is required to set extension type", path)
+	}
+	extensionTypes.Register(pattern, reflect.TypeOf(sample))
+	return nil
+}
+
+// decodeExtensions replaces extensions declared by dict, with a registered type, by their typed value
+func decodeExtensions(dict map[string]any, p tree.Path) error {
+	keys := utils.MapKeys(dict)
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch v := dict[key].(type) {
+		case map[string]any:
+			if key != consts.Extensions {
+				if err := decodeExtensions(v, p.Next(key)); err != nil {
+					return err
+				}
+				continue
+			}
+			names := utils.MapKeys(v)
+			sort.Strings(names)
+			for _, name := range names {
+				t, ok := extensionTypes.Match(p.Next(name))
+				if !ok {
+					continue
+				}
+				target := reflect.New(t)
+				if err := Transform(v[name], target.Interface()); err != nil {
+					return fmt.Errorf("%s: %w", p.Next(name), err)
+				}
+				v[name] = target.Elem().Interface()
+			}
+		case []any:
+			for _, e := range v {
+				if m, ok := e.(map[string]any); ok {
+					if err := decodeExtensions(m, p.Next(tree.PathMatchList)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/compose-spec/compose-go/v2/consts"
 	"github.com/compose-spec/compose-go/v2/dotenv"
 	interp "github.com/compose-spec/compose-go/v2/interpolation"
 	"github.com/compose-spec/compose-go/v2/types"
@@ -40,15 +41,27 @@ func loadIncludeConfig(source any) ([]types.IncludeConfig, error) {
 }
 
 func ApplyInclude(ctx context.Context, configDetails types.ConfigDetails, model map[string]any, options *Options, included []string) error {
+	return applyInclude(ctx, configDetails, model, options, included, nil)
+}
+
+// applyInclude loads included compose files into model. includeFiles is the compose file declaring include, by
+// included path, defaulting to the first compose file
+func applyInclude(ctx context.Context, configDetails types.ConfigDetails, model map[string]any, options *Options, included []string, includeFiles map[string]string) error {
 	includeConfig, err := loadIncludeConfig(model["include"])
 	if err != nil {
 		return err
 	}
 	for _, r := range includeConfig {
+		declaringFile, ok := includeFiles[r.Path[0]]
+		if !ok {
+			declaringFile = configDetails.ConfigFiles[0].Filename
+		}
+		// resource loaders get the compose file declaring include, as for extends
+		fctx := context.WithValue(ctx, consts.ComposeFileKey{}, declaringFile)
 		for i, p := range r.Path {
 			for _, loader := range options.ResourceLoaders {
 				if loader.Accept(p) {
-					path, err := loader.Load(ctx, p)
+					path, err := loader.Load(fctx, p)
 					if err != nil {
 						return err
 					}
@@ -69,6 +82,11 @@ func ApplyInclude(ctx context.Context, configDetails types.ConfigDetails, model
 
 		if r.ProjectDirectory == "" {
 			r.ProjectDirectory = filepath.Dir(mainFile)
+		} else {
+			r.ProjectDirectory = absPath(configDetails.WorkingDir, r.ProjectDirectory)
+		}
+		for i, f := range r.EnvFile {
+			r.EnvFile[i] = absPath(configDetails.WorkingDir, f)
 		}
 
 		loadOptions := options.clone()
@@ -76,6 +94,13 @@ func ApplyInclude(ctx context.Context, configDetails types.ConfigDetails, model
 		loadOptions.SkipNormalization = true
 		loadOptions.SkipConsistencyCheck = true
 
+		options.processEvent(EventInclude, map[string]any{
+			"file":              declaringFile,
+			"path":              r.Path,
+			"project_directory": r.ProjectDirectory,
+			"env_file":          r.EnvFile,
+		})
+
 		envFromFile, err := dotenv.GetEnvFromFile(configDetails.Environment, r.ProjectDirectory, r.EnvFile)
 		if err != nil {
 			return err
@@ -90,12 +115,19 @@ func ApplyInclude(ctx context.Context, configDetails types.ConfigDetails, model
 			Substitute:      options.Interpolate.Substitute,
 			LookupValue:     config.LookupEnv,
 			TypeCastMapping: options.Interpolate.TypeCastMapping,
+			Diagnostics:     options.Interpolate.Diagnostics,
 		}
 		imported, err := loadYamlModel(ctx, config, loadOptions, &cycleTracker{}, included)
 		if err != nil {
 			return err
 		}
-		err = importResources(imported, model)
+		err = importResources(imported, model, func(key, name string) {
+			options.processEvent(EventImport, map[string]any{
+				"resource": key,
+				"name":     name,
+				"file":     mainFile,
+			})
+		})
 		if err != nil {
 			return err
 		}
@@ -104,27 +136,28 @@ func ApplyInclude(ctx context.Context, configDetails types.ConfigDetails, model
 	return nil
 }
 
-// importResources import into model all resources defined by imported, and report error on conflict
-func importResources(source map[string]any, target map[string]any) error {
-	if err := importResource(source, target, "services"); err != nil {
+// importResources import into model all resources defined by imported, and report error on conflict.
+// onImport is invoked for each resource added to model
+func importResources(source map[string]any, target map[string]any, onImport func(key, name string)) error {
+	if err := importResource(source, target, "services", onImport); err != nil {
 		return err
 	}
-	if err := importResource(source, target, "volumes"); err != nil {
+	if err := importResource(source, target, "volumes", onImport); err != nil {
 		return err
 	}
-	if err := importResource(source, target, "networks"); err != nil {
+	if err := importResource(source, target, "networks", onImport); err != nil {
 		return err
 	}
-	if err := importResource(source, target, "secrets"); err != nil {
+	if err := importResource(source, target, "secrets", onImport); err != nil {
 		return err
 	}
-	if err := importResource(source, target, "configs"); err != nil {
+	if err := importResource(source, target, "configs", onImport); err != nil {
 		return err
 	}
 	return nil
 }
 
-func importResource(source map[string]any, target map[string]any, key string) error {
+func importResource(source map[string]any, target map[string]any, key string, onImport func(key, name string)) error {
 	from := source[key]
 	if from != nil {
 		var to map[string]any
@@ -141,8 +174,74 @@ func importResource(source map[string]any, target map[string]any, key string) er
 				return fmt.Errorf("%s.%s conflicts with imported resource", key, name)
 			}
 			to[name] = a
+			onImport(key, name)
 		}
 		target[key] = to
 	}
 	return nil
 }
+
+// includePaths returns the paths, as declared, of the first file of each include entry
+func includePaths(include any) []string {
+	entries, _ := include.([]any)
+	var paths []string
+	for _, e := range entries {
+		switch e := e.(type) {
+		case string:
+			paths = append(paths, e)
+		case map[string]any:
+			switch p := e["path"].(type) {
+			case string:
+				paths = append(paths, p)
+			case []any:
+				if len(p) > 0 {
+					if s, ok := p[0].(string); ok {
+						paths = append(paths, s)
+					}
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// recordOrigins records file as origin for resources declared by model, unless already set
+func recordOrigins(origins map[string]map[string]string, model map[string]any, file string) {
+	for _, resource := range []string{"services", "networks", "volumes"} {
+		declared, _ := model[resource].(map[string]any)
+		for name := range declared {
+			recordOrigin(origins, resource, name, file)
+		}
+	}
+}
+
+func recordOrigin(origins map[string]map[string]string, resource, name, file string) {
+	if origins[resource] == nil {
+		origins[resource] = map[string]string{}
+	}
+	if _, ok := origins[resource][name]; !ok {
+		origins[resource][name] = file
+	}
+}
+
+// setOrigins sets Origin for services, networks and volumes, as recorded while loading
+func setOrigins(project *types.Project, origins map[string]map[string]string) {
+	for name, origin := range origins["services"] {
+		if s, ok := project.Services[name]; ok {
+			s.Origin = origin
+			project.Services[name] = s
+		}
+	}
+	for name, origin := range origins["networks"] {
+		if n, ok := project.Networks[name]; ok {
+			n.Origin = origin
+			project.Networks[name] = n
+		}
+	}
+	for name, origin := range origins["volumes"] {
+		if v, ok := project.Volumes[name]; ok {
+			v.Origin = origin
+			project.Volumes[name] = v
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// inputFilesRecorder collects files read while loading a project, based on loading events. Compose files are
+// recorded with the content loader actually parsed.
+type inputFilesRecorder struct {
+	files []types.InputFile
+	seen  map[types.InputFile]bool
+	// kinds is the role of compose files about to be loaded, by path
+	kinds map[string]types.InputFileKind
+}
+
+func newInputFilesRecorder() *inputFilesRecorder {
+	return &inputFilesRecorder{
+		seen:  map[types.InputFile]bool{},
+		kinds: map[string]types.InputFileKind{},
+	}
+}
+
+// listen implements Listener
+func (r *inputFilesRecorder) listen(event string, metadata map[string]any) {
+	switch event {
+	case EventLoad:
+		file := metadata["file"].(string)
+		kind, ok := r.kinds[file]
+		if !ok {
+			kind = types.InputFileCompose
+		}
+		r.add(types.NewInputFile(kind, file, metadata["content"].([]byte)))
+	case EventInclude:
+		for _, p := range metadata["path"].(types.StringList) {
+			r.kinds[p] = types.InputFileInclude
+		}
+		envFiles := metadata["env_file"].(types.StringList)
+		if len(envFiles) == 0 {
+			r.read(types.InputFileDotEnv, filepath.Join(metadata["project_directory"].(string), ".env"))
+		}
+		for _, f := range envFiles {
+			r.read(types.InputFileDotEnv, f)
+		}
+	case EventExtends:
+		r.kinds[metadata["file"].(string)] = types.InputFileExtends
+	case EventEnvFile:
+		r.read(types.InputFileEnvFile, metadata["file"].(string))
+	}
+}
+
+// read records file at path, ignoring it if it isn't a regular file, as loader doesn't read it either. File is
+// recorded without a digest if it can't be read, as recording input files must not prevent loading the project.
+func (r *inputFilesRecorder) read(kind types.InputFileKind, path string) {
+	stat, err := os.Stat(path)
+	if err != nil || !stat.Mode().IsRegular() {
+		return
+	}
+	f, err := types.ReadInputFile(kind, path)
+	if err != nil {
+		f = types.InputFile{Path: path, Kind: kind}
+	}
+	r.add(f)
+}
+
+func (r *inputFilesRecorder) add(f types.InputFile) {
+	if r.seen[f] {
+		return
+	}
+	r.seen[f] = true
+	r.files = append(r.files, f)
+}
+
+// recordFileObjects records secrets and configs with a `file` source
+func (r *inputFilesRecorder) recordFileObjects(project *types.Project) {
+	for _, name := range project.SecretNames() {
+		if f := project.Secrets[name].File; f != "" {
+			r.read(types.InputFileSecret, f)
+		}
+	}
+	for _, name := range project.ConfigNames() {
+		if f := project.Configs[name].File; f != "" {
+			r.read(types.InputFileConfig, f)
+		}
+	}
+}
@@ -17,57 +17,53 @@
 package loader
 
 import (
-	"strconv"
-	"strings"
-
+	"github.com/compose-spec/compose-go/v2/internal/decode"
 	interp "github.com/compose-spec/compose-go/v2/interpolation"
 	"github.com/compose-spec/compose-go/v2/tree"
-	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 )
 
 var interpolateTypeCastMapping = map[tree.Path]interp.Cast{
-	servicePath("configs", tree.PathMatchList, "mode"):             toInt,
-	servicePath("cpu_count"):                                       toInt64,
-	servicePath("cpu_percent"):                                     toFloat,
-	servicePath("cpu_period"):                                      toInt64,
-	servicePath("cpu_quota"):                                       toInt64,
-	servicePath("cpu_rt_period"):                                   toInt64,
-	servicePath("cpu_rt_runtime"):                                  toInt64,
-	servicePath("cpus"):                                            toFloat32,
-	servicePath("cpu_shares"):                                      toInt64,
-	servicePath("init"):                                            toBoolean,
-	servicePath("deploy", "replicas"):                              toInt,
-	servicePath("deploy", "update_config", "parallelism"):          toInt,
-	servicePath("deploy", "update_config", "max_failure_ratio"):    toFloat,
-	servicePath("deploy", "rollback_config", "parallelism"):        toInt,
-	servicePath("deploy", "rollback_config", "max_failure_ratio"):  toFloat,
-	servicePath("deploy", "restart_policy", "max_attempts"):        toInt,
-	servicePath("deploy", "placement", "max_replicas_per_node"):    toInt,
-	servicePath("healthcheck", "retries"):                          toInt,
-	servicePath("healthcheck", "disable"):                          toBoolean,
-	servicePath("oom_kill_disable"):                                toBoolean,
-	servicePath("oom_score_adj"):                                   toInt64,
-	servicePath("pids_limit"):                                      toInt64,
-	servicePath("ports", tree.PathMatchList, "target"):             toInt,
-	servicePath("privileged"):                                      toBoolean,
-	servicePath("read_only"):                                       toBoolean,
-	servicePath("scale"):                                           toInt,
-	servicePath("secrets", tree.PathMatchList, "mode"):             toInt,
-	servicePath("stdin_open"):                                      toBoolean,
-	servicePath("tty"):                                             toBoolean,
-	servicePath("ulimits", tree.PathMatchAll):                      toInt,
-	servicePath("ulimits", tree.PathMatchAll, "hard"):              toInt,
-	servicePath("ulimits", tree.PathMatchAll, "soft"):              toInt,
-	servicePath("volumes", tree.PathMatchList, "read_only"):        toBoolean,
-	servicePath("volumes", tree.PathMatchList, "volume", "nocopy"): toBoolean,
-	iPath("networks", tree.PathMatchAll, "external"):               toBoolean,
-	iPath("networks", tree.PathMatchAll, "internal"):               toBoolean,
-	iPath("networks", tree.PathMatchAll, "attachable"):             toBoolean,
-	iPath("networks", tree.PathMatchAll, "enable_ipv6"):            toBoolean,
-	iPath("volumes", tree.PathMatchAll, "external"):                toBoolean,
-	iPath("secrets", tree.PathMatchAll, "external"):                toBoolean,
-	iPath("configs", tree.PathMatchAll, "external"):                toBoolean,
+	servicePath("configs", tree.PathMatchList, "mode"):             decode.ToInt,
+	servicePath("cpu_count"):                                       decode.ToInt64,
+	servicePath("cpu_percent"):                                     decode.ToFloat,
+	servicePath("cpu_period"):                                      decode.ToInt64,
+	servicePath("cpu_quota"):                                       decode.ToInt64,
+	servicePath("cpu_rt_period"):                                   decode.ToInt64,
+	servicePath("cpu_rt_runtime"):                                  decode.ToInt64,
+	servicePath("cpus"):                                            decode.ToFloat32,
+	servicePath("cpu_shares"):                                      decode.ToInt64,
+	servicePath("init"):                                            decode.ToBoolean,
+	servicePath("deploy", "replicas"):                              decode.ToInt,
+	servicePath("deploy", "update_config", "parallelism"):          decode.ToInt,
+	servicePath("deploy", "update_config", "max_failure_ratio"):    decode.ToFloat,
+	servicePath("deploy", "rollback_config", "parallelism"):        decode.ToInt,
+	servicePath("deploy", "rollback_config", "max_failure_ratio"):  decode.ToFloat,
+	servicePath("deploy", "restart_policy", "max_attempts"):        decode.ToInt,
+	servicePath("deploy", "placement", "max_replicas_per_node"):    decode.ToInt,
+	servicePath("healthcheck", "retries"):                          decode.ToInt,
+	servicePath("healthcheck", "disable"):                          decode.ToBoolean,
+	servicePath("oom_kill_disable"):                                decode.ToBoolean,
+	servicePath("oom_score_adj"):                                   decode.ToInt64,
+	servicePath("pids_limit"):                                      decode.ToInt64,
+	servicePath("ports", tree.PathMatchList, "target"):             decode.ToInt,
+	servicePath("privileged"):                                      decode.ToBoolean,
+	servicePath("read_only"):                                       decode.ToBoolean,
+	servicePath("scale"):                                           decode.ToInt,
+	servicePath("secrets", tree.PathMatchList, "mode"):             decode.ToInt,
+	servicePath("stdin_open"):                                      decode.ToBoolean,
+	servicePath("tty"):                                             decode.ToBoolean,
+	servicePath("ulimits", tree.PathMatchAll):                      decode.ToInt,
+	servicePath("ulimits", tree.PathMatchAll, "hard"):              decode.ToInt,
+	servicePath("ulimits", tree.PathMatchAll, "soft"):              decode.ToInt,
+	servicePath("volumes", tree.PathMatchList, "read_only"):        decode.ToBoolean,
+	servicePath("volumes", tree.PathMatchList, "volume", "nocopy"): decode.ToBoolean,
+	iPath("networks", tree.PathMatchAll, "external"):               decode.ToBoolean,
+	iPath("networks", tree.PathMatchAll, "internal"):               decode.ToBoolean,
+	iPath("networks", tree.PathMatchAll, "attachable"):             decode.ToBoolean,
+	iPath("networks", tree.PathMatchAll, "enable_ipv6"):            decode.ToBoolean,
+	iPath("volumes", tree.PathMatchAll, "external"):                decode.ToBoolean,
+	iPath("secrets", tree.PathMatchAll, "external"):                decode.ToBoolean,
+	iPath("configs", tree.PathMatchAll, "external"):                decode.ToBoolean,
 }
 
 func iPath(parts ...string) tree.Path {
@@ -77,41 +73,3 @@ func iPath(parts ...string) tree.Path {
 func servicePath(parts ...string) tree.Path {
 	return iPath(append([]string{"services", tree.PathMatchAll}, parts...)...)
 }
-
-func toInt(value string) (interface{}, error) {
-	return strconv.Atoi(value)
-}
-
-func toInt64(value string) (interface{}, error) {
-	return strconv.ParseInt(value, 10, 64)
-}
-
-func toFloat(value string) (interface{}, error) {
-	return strconv.ParseFloat(value, 64)
-}
-
-func toFloat32(value string) (interface{}, error) {
-	f, err := strconv.ParseFloat(value, 32)
-	if err != nil {
-		return nil, err
-	}
-	return float32(f), nil
-}
-
-// should match http://yaml.org/type/bool.html
-func toBoolean(value string) (interface{}, error) {
-	switch strings.ToLower(value) {
-	case "true":
-		return true, nil
-	case "false":
-		return false, nil
-	case "y", "yes", "on":
-		logrus.Warnf("%q for boolean is not supported by YAML 1.2, please use `true`", value)
-		return true, nil
-	case "n", "no", "off":
-		logrus.Warnf("%q for boolean is not supported by YAML 1.2, please use `false`", value)
-		return false, nil
-	default:
-		return nil, errors.Errorf("invalid boolean: %s", value)
-	}
-}
@@ -25,18 +25,21 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/consts"
+	"github.com/compose-spec/compose-go/v2/diagnostics"
+	"github.com/compose-spec/compose-go/v2/internal/decode"
 	interp "github.com/compose-spec/compose-go/v2/interpolation"
 	"github.com/compose-spec/compose-go/v2/override"
 	"github.com/compose-spec/compose-go/v2/paths"
 	"github.com/compose-spec/compose-go/v2/schema"
-	"github.com/compose-spec/compose-go/v2/template"
 	"github.com/compose-spec/compose-go/v2/transform"
 	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/utils"
 	"github.com/compose-spec/compose-go/v2/validation"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -57,6 +60,15 @@ type Options struct {
 	ConvertWindowsPaths bool
 	// Skip consistency check
 	SkipConsistencyCheck bool
+	// TargetVersion is the version of the Compose Specification, as released by Docker Compose, the compose model
+	// must be supported by. Using attributes or YAML tags introduced by a later version (see schema.Features) is
+	// reported as errdefs.ErrUnsupported. Defaults to all attributes being supported
+	TargetVersion string
+	// CheckPortConflicts enables checking host ports published by services can all be allocated
+	CheckPortConflicts bool
+	// CheckResources enables checking service-level `cpus`, `mem_limit`, `mem_reservation` and `pids_limit` agree
+	// with `deploy.resources`, and limits are at least reservations
+	CheckResources bool
 	// Skip extends
 	SkipExtends bool
 	// SkipInclude will ignore `include` and only load model from file(s) set by ConfigDetails
@@ -75,6 +87,53 @@ type Options struct {
 	Profiles []string
 	// ResourceLoaders manages support for remote resources
 	ResourceLoaders []ResourceLoader
+	// Listeners are notified about events while loading the compose model
+	Listeners []Listener
+	// Diagnostics receives warnings about the compose model, like usage of deprecated attributes. Defaults
+	// to diagnostics.Logrus
+	Diagnostics diagnostics.Sink
+	// implicitBuildContexts collects services' build sections without an explicit context
+	implicitBuildContexts *[]tree.Path
+	// origins collects the compose file declaring resources, by resource type and name. It is not cloned, as
+	// resources loaded by include or extends are not declared by the loaded compose files
+	origins map[string]map[string]string
+}
+
+// Listener is notified about loading events, with metadata describing the event
+type Listener = func(event string, metadata map[string]any)
+
+const (
+	// EventLoad is emitted when a compose file is read, `file` metadata is set with file path and `content` with
+	// the content being parsed
+	EventLoad = "load"
+	// EventInclude is emitted when a compose file includes another, `file` metadata is set with the
+	// including file, `path`, `project_directory` and `env_file` by the resolved include configuration
+	EventInclude = "include"
+	// EventImport is emitted when a resource is imported by `include`, `resource` metadata is set with
+	// the resource type (services, networks, ...), `name` with resource name and `file` with the
+	// included compose file declaring it
+	EventImport = "import"
+	// EventExtends is emitted when a service extends another one declared in a distinct file, `service`
+	// and `file` metadata are set with the extending service name and the local path of extended file
+	EventExtends = "extends"
+	// EventEnvFile is emitted when a service declares an `env_file`, `service` and `file` metadata
+	// are set with service name and env_file path
+	EventEnvFile = "env_file"
+)
+
+// processEvent notifies registered listeners about a loading event
+func (o *Options) processEvent(event string, metadata map[string]any) {
+	for _, l := range o.Listeners {
+		l(event, metadata)
+	}
+}
+
+// sink returns the Sink diagnostics are reported to
+func (o *Options) sink() diagnostics.Sink {
+	if o.Diagnostics == nil {
+		return diagnostics.Logrus
+	}
+	return o.Diagnostics
 }
 
 // ResourceLoader is a plugable remote resource resolver
@@ -113,6 +172,9 @@ func (o *Options) clone() *Options {
 		ResolvePaths:               o.ResolvePaths,
 		ConvertWindowsPaths:        o.ConvertWindowsPaths,
 		SkipConsistencyCheck:       o.SkipConsistencyCheck,
+		TargetVersion:              o.TargetVersion,
+		CheckPortConflicts:         o.CheckPortConflicts,
+		CheckResources:             o.CheckResources,
 		SkipExtends:                o.SkipExtends,
 		SkipInclude:                o.SkipInclude,
 		Interpolate:                o.Interpolate,
@@ -121,6 +183,9 @@ func (o *Options) clone() *Options {
 		projectNameImperativelySet: o.projectNameImperativelySet,
 		Profiles:                   o.Profiles,
 		ResourceLoaders:            o.ResourceLoaders,
+		Listeners:                  o.Listeners,
+		Diagnostics:                o.Diagnostics,
+		implicitBuildContexts:      o.implicitBuildContexts,
 	}
 }
 
@@ -245,7 +310,6 @@ func LoadWithContext(ctx context.Context, configDetails types.ConfigDetails, opt
 
 	opts := &Options{
 		Interpolate: &interp.Options{
-			Substitute:      template.Substitute,
 			LookupValue:     configDetails.LookupEnv,
 			TypeCastMapping: interpolateTypeCastMapping,
 		},
@@ -278,6 +342,8 @@ func loadYamlModel(ctx context.Context, config types.ConfigDetails, opts *Option
 	var (
 		dict = map[string]interface{}{}
 		err  error
+		// includeFiles is the compose file declaring an include, by include path
+		includeFiles = map[string]string{}
 	)
 	for _, file := range config.ConfigFiles {
 		fctx := context.WithValue(ctx, consts.ComposeFileKey{}, file.Filename)
@@ -288,6 +354,14 @@ func loadYamlModel(ctx context.Context, config types.ConfigDetails, opts *Option
 			}
 			file.Content = content
 		}
+		content := file.Content
+		if file.Config != nil {
+			// in-memory model is identified by its yaml representation
+			if content, err = yaml.Marshal(file.Config); err != nil {
+				return nil, err
+			}
+		}
+		opts.processEvent(EventLoad, map[string]any{"file": file.Filename, "content": content})
 
 		processRawYaml := func(raw interface{}, processors ...PostProcessor) error {
 			converted, err := convertToStringKeysRecursive(raw, "")
@@ -300,7 +374,12 @@ func loadYamlModel(ctx context.Context, config types.ConfigDetails, opts *Option
 			}
 
 			if opts.Interpolate != nil && !opts.SkipInterpolation {
-				cfg, err = interp.Interpolate(cfg, *opts.Interpolate)
+				interpolate := *opts.Interpolate
+				if interpolate.Diagnostics == nil {
+					interpolate.Diagnostics = opts.sink()
+				}
+				interpolate.Diagnostics = diagnostics.WithFile(interpolate.Diagnostics, file.Filename)
+				cfg, err = interp.Interpolate(cfg, interpolate)
 				if err != nil {
 					return err
 				}
@@ -308,10 +387,39 @@ func loadYamlModel(ctx context.Context, config types.ConfigDetails, opts *Option
 
 			fixEmptyNotNull(cfg)
 
+			for _, p := range includePaths(cfg["include"]) {
+				if _, ok := includeFiles[p]; !ok {
+					includeFiles[p] = file.Filename
+				}
+			}
+			if opts.origins != nil {
+				recordOrigins(opts.origins, cfg, file.Filename)
+			}
+			reportExternalName(cfg, diagnostics.WithFile(opts.sink(), file.Filename))
+
 			if !opts.SkipValidation {
 				if err := schema.Validate(cfg); err != nil {
 					return fmt.Errorf("validating %s: %w", file.Filename, err)
 				}
+				for _, processor := range processors {
+					if reset, ok := processor.(*ResetProcessor); ok {
+						if err := reset.validateItems(); err != nil {
+							return fmt.Errorf("validating %s: %w", file.Filename, err)
+						}
+					}
+				}
+			}
+
+			if opts.TargetVersion != "" {
+				used := schema.UsedFeatures(cfg)
+				for _, processor := range processors {
+					if reset, ok := processor.(*ResetProcessor); ok {
+						used = append(used, reset.tags...)
+					}
+				}
+				if err := schema.CheckFeaturesVersion(used, opts.TargetVersion); err != nil {
+					return fmt.Errorf("validating %s: %w", file.Filename, err)
+				}
 			}
 
 			if !opts.SkipExtends {
@@ -358,6 +466,17 @@ func loadYamlModel(ctx context.Context, config types.ConfigDetails, opts *Option
 		return nil, err
 	}
 
+	if !opts.SkipValidation {
+		// extensions can be partially declared by override files, so they are validated once merged
+		if err := schema.ValidateExtensions(dict); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.implicitBuildContexts != nil {
+		*opts.implicitBuildContexts = append(*opts.implicitBuildContexts, implicitBuildContexts(dict)...)
+	}
+
 	dict, err = transform.Canonical(dict)
 	if err != nil {
 		return nil, err
@@ -365,7 +484,7 @@ func loadYamlModel(ctx context.Context, config types.ConfigDetails, opts *Option
 
 	if !opts.SkipInclude {
 		included = append(included, config.ConfigFiles[0].Filename)
-		err = ApplyInclude(ctx, config, dict, opts, included)
+		err = applyInclude(ctx, config, dict, opts, included, includeFiles)
 		if err != nil {
 			return nil, err
 		}
@@ -401,6 +520,29 @@ func load(ctx context.Context, configDetails types.ConfigDetails, opts *Options,
 
 	includeRefs := make(map[string][]types.IncludeConfig)
 
+	origins := map[string]map[string]string{}
+	recordIncludes := func(event string, metadata map[string]any) {
+		switch event {
+		case EventInclude:
+			file := metadata["file"].(string)
+			includeRefs[file] = append(includeRefs[file], types.IncludeConfig{
+				Path:             metadata["path"].(types.StringList),
+				ProjectDirectory: metadata["project_directory"].(string),
+				EnvFile:          metadata["env_file"].(types.StringList),
+			})
+		case EventImport:
+			// nested includes are imported first, so the first origin recorded is the declaring file
+			recordOrigin(origins, metadata["resource"].(string), metadata["name"].(string), metadata["file"].(string))
+		}
+	}
+	opts.origins = origins
+
+	var implicitContexts []tree.Path
+	opts.implicitBuildContexts = &implicitContexts
+
+	recorder := newInputFilesRecorder()
+	opts.Listeners = append(append([]Listener{}, opts.Listeners...), recorder.listen, recordIncludes)
+
 	dict, err := loadYamlModel(ctx, configDetails, opts, &cycleTracker{}, nil)
 	if err != nil {
 		return nil, err
@@ -416,7 +558,12 @@ func load(ctx context.Context, configDetails types.ConfigDetails, opts *Options,
 		Environment: configDetails.Environment,
 	}
 	delete(dict, "name") // project name set by yaml must be identified by caller as opts.projectName
-	err = Transform(dict, project)
+	if extensionTypes.Len() > 0 {
+		if err := decodeExtensions(dict, tree.NewPath()); err != nil {
+			return nil, err
+		}
+	}
+	err = transformModel(dict, project, opts.sink())
 	if err != nil {
 		return nil, err
 	}
@@ -424,9 +571,17 @@ func load(ctx context.Context, configDetails types.ConfigDetails, opts *Options,
 	if len(includeRefs) != 0 {
 		project.IncludeReferences = includeRefs
 	}
+	setOrigins(project, origins)
+	for _, path := range implicitContexts {
+		// build context declared by an included or extended file is relative to another directory
+		s, ok := project.Services[path.Parts()[1]]
+		if ok && s.Build != nil && (s.Build.Context == "." || s.Build.Context == configDetails.WorkingDir) {
+			project.AddImplicitDefault(path)
+		}
+	}
 
 	if !opts.SkipNormalization {
-		err := Normalize(project)
+		err := normalize(project, opts.sink())
 		if err != nil {
 			return nil, err
 		}
@@ -450,6 +605,32 @@ func load(ctx context.Context, configDetails types.ConfigDetails, opts *Options,
 
 	project.ApplyProfiles(opts.Profiles)
 
+	if opts.CheckPortConflicts {
+		// only services enabled by active profiles can conflict
+		if err := checkPortConflicts(project); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.CheckResources {
+		for _, name := range project.ServiceNames() {
+			if err := checkResources(project.Services[name]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	all := project.AllServices()
+	names := utils.MapKeys(all)
+	sort.Strings(names)
+	for _, name := range names {
+		for _, f := range all[name].EnvFile {
+			opts.processEvent(EventEnvFile, map[string]any{"service": name, "file": f})
+		}
+	}
+	recorder.recordFileObjects(project)
+	project.InputFiles = recorder.files
+
 	if !opts.SkipResolveEnvironment {
 		err := project.ResolveServicesEnvironment(opts.discardEnvFiles)
 		if err != nil {
@@ -460,6 +641,21 @@ func load(ctx context.Context, configDetails types.ConfigDetails, opts *Options,
 	return project, nil
 }
 
+// implicitBuildContexts returns the path to services' build context when not explicitly set
+func implicitBuildContexts(dict map[string]any) []tree.Path {
+	var paths []tree.Path
+	services, _ := dict["services"].(map[string]any)
+	for name, s := range services {
+		service, _ := s.(map[string]any)
+		if build, ok := service["build"].(map[string]any); ok {
+			if _, ok := build["context"]; !ok {
+				paths = append(paths, tree.NewPath("services", name, "build", "context"))
+			}
+		}
+	}
+	return paths
+}
+
 func InvalidProjectNameErr(v string) error {
 	return fmt.Errorf(
 		"invalid project name %q: must consist only of lowercase alphanumeric characters, hyphens, and underscores as well as start with a letter or number",
@@ -578,12 +774,17 @@ func groupXFieldsIntoExtensions(dict map[string]interface{}, p tree.Path) map[st
 // Transform converts the source into the target struct with compose types transformer
 // and the specified transformers if any.
 func Transform(source interface{}, target interface{}) error {
+	return transformModel(source, target, diagnostics.Logrus)
+}
+
+// transformModel converts the source into the target struct, reporting YAML 1.1 booleans to sink
+func transformModel(source interface{}, target interface{}, sink diagnostics.Sink) error {
 	data := mapstructure.Metadata{}
 	config := &mapstructure.DecoderConfig{
 		DecodeHook: mapstructure.ComposeDecodeHookFunc(
 			nameServices,
-			decoderHook,
-			cast),
+			decode.Hook,
+			decode.CastWithDiagnostics(sink)),
 		Result:   target,
 		TagName:  "yaml",
 		Metadata: &data,
@@ -31,6 +31,11 @@ import (
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 
+	"github.com/compose-spec/compose-go/v2/diagnostics"
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/override"
+	"github.com/compose-spec/compose-go/v2/schema"
+	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/compose-spec/compose-go/v2/types"
 )
 
@@ -259,6 +264,23 @@ var sampleConfig = types.Config{
 	},
 }
 
+// withOrigin returns a copy of services, networks or volumes with Origin set to file
+func withOrigin[M ~map[string]T, T types.ServiceConfig | types.NetworkConfig | types.VolumeConfig](resources M, file string) M {
+	cp := make(M, len(resources))
+	for name, r := range resources {
+		switch r := any(&r).(type) {
+		case *types.ServiceConfig:
+			r.Origin = file
+		case *types.NetworkConfig:
+			r.Origin = file
+		case *types.VolumeConfig:
+			r.Origin = file
+		}
+		cp[name] = r
+	}
+	return cp
+}
+
 func TestParseYAML(t *testing.T) {
 	dict, err := ParseYAML([]byte(sampleYAML))
 	assert.NilError(t, err)
@@ -271,9 +293,9 @@ func TestLoad(t *testing.T) {
 		options.SkipConsistencyCheck = true
 	})
 	assert.NilError(t, err)
-	assert.Check(t, is.DeepEqual(sampleConfig.Services, actual.Services))
-	assert.Check(t, is.DeepEqual(sampleConfig.Networks, actual.Networks))
-	assert.Check(t, is.DeepEqual(sampleConfig.Volumes, actual.Volumes))
+	assert.Check(t, is.DeepEqual(withOrigin(sampleConfig.Services, "filename0.yml"), actual.Services))
+	assert.Check(t, is.DeepEqual(withOrigin(sampleConfig.Networks, "filename0.yml"), actual.Networks))
+	assert.Check(t, is.DeepEqual(withOrigin(sampleConfig.Volumes, "filename0.yml"), actual.Volumes))
 }
 
 func TestLoadFromFile(t *testing.T) {
@@ -297,9 +319,9 @@ func TestLoadFromFile(t *testing.T) {
 		options.SkipConsistencyCheck = true
 	})
 	assert.NilError(t, err)
-	assert.Check(t, is.DeepEqual(sampleConfig.Services, actual.Services))
-	assert.Check(t, is.DeepEqual(sampleConfig.Networks, actual.Networks))
-	assert.Check(t, is.DeepEqual(sampleConfig.Volumes, actual.Volumes))
+	assert.Check(t, is.DeepEqual(withOrigin(sampleConfig.Services, tmpPath), actual.Services))
+	assert.Check(t, is.DeepEqual(withOrigin(sampleConfig.Networks, tmpPath), actual.Networks))
+	assert.Check(t, is.DeepEqual(withOrigin(sampleConfig.Volumes, tmpPath), actual.Volumes))
 }
 
 func TestLoadExtensions(t *testing.T) {
@@ -439,6 +461,190 @@ services:
 	assert.Check(t, len(foo.Volumes) == 0)
 }
 
+func TestLoadWithOverrideTag(t *testing.T) {
+	base := `
+name: load-override
+services:
+  foo:
+    image: alpine
+    ports:
+      - 8080:80
+      - 9090:90
+    environment:
+      FOO: foo
+      BAR: bar
+    labels:
+      - com.example=base
+`
+	override := `
+services:
+  foo:
+    ports: !override
+      - 8443:443
+    environment: !override
+      ZOT: zot
+    labels:
+      - com.example.extra=override
+`
+	p, err := Load(buildConfigDetailsMultipleFiles(nil, base, override), func(options *Options) {
+		options.SkipNormalization = true
+	})
+	assert.NilError(t, err)
+	foo := p.Services["foo"]
+	assert.DeepEqual(t, foo.Ports, []types.ServicePortConfig{
+		{Mode: "ingress", Target: 443, Published: "8443", Protocol: "tcp"},
+	})
+	assert.DeepEqual(t, foo.Environment, types.MappingWithEquals{"ZOT": strPtr("zot")})
+	assert.DeepEqual(t, foo.Labels, types.Labels{"com.example": "base", "com.example.extra": "override"})
+
+	p, err = loadYAML(`
+name: load-extends-override
+services:
+  foo:
+    extends:
+      service: bar
+    volumes: !override
+      - ./data:/data
+  bar:
+    image: alpine
+    volumes:
+      - .:/src
+`)
+	assert.NilError(t, err)
+	foo = p.Services["foo"]
+	assert.Equal(t, len(foo.Volumes), 1)
+	assert.Equal(t, foo.Volumes[0].Target, "/data")
+}
+
+func TestLoadWithResetSequenceItems(t *testing.T) {
+	base := `
+name: load-reset-items
+services:
+  foo:
+    image: alpine
+    ports:
+      - 8080:80
+      - 9090:90
+    volumes:
+      - ./src:/src
+      - data:/data
+    environment:
+      FOO: foo
+      BAR: bar
+    expose:
+      - 80
+      - 90
+    secrets:
+      - one
+      - two
+volumes:
+  data: {}
+secrets:
+  one:
+    file: ./one.txt
+  two:
+    file: ./two.txt
+`
+	override := `
+services:
+  foo:
+    ports:
+      - !reset 9090:90
+    volumes:
+      - !reset
+        type: volume
+        target: /data
+    environment:
+      - !reset BAR
+    expose:
+      - !reset 90
+    secrets:
+      - !reset two
+`
+	p, err := Load(buildConfigDetailsMultipleFiles(nil, base, override), func(options *Options) {
+		options.SkipNormalization = true
+		options.ResolvePaths = false
+	})
+	assert.NilError(t, err)
+	foo := p.Services["foo"]
+	assert.DeepEqual(t, foo.Ports, []types.ServicePortConfig{
+		{Mode: "ingress", Target: 80, Published: "8080", Protocol: "tcp"},
+	})
+	assert.Equal(t, len(foo.Volumes), 1)
+	assert.Equal(t, foo.Volumes[0].Target, "/src")
+	assert.DeepEqual(t, foo.Environment, types.MappingWithEquals{"FOO": strPtr("foo")})
+	assert.DeepEqual(t, foo.Expose, types.StringOrNumberList{"80"})
+	assert.DeepEqual(t, foo.Secrets, []types.ServiceSecretConfig{{Source: "one"}})
+}
+
+func TestLoadWithResetPortLongSyntax(t *testing.T) {
+	base := `
+name: load-reset-port
+services:
+  foo:
+    image: alpine
+    ports:
+      - 8080:80
+      - 9090:90
+`
+	override := `
+services:
+  foo:
+    ports:
+      - !reset {target: 80, published: "8080"}
+`
+	p, err := Load(buildConfigDetailsMultipleFiles(nil, base, override), func(options *Options) {
+		options.SkipNormalization = true
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, p.Services["foo"].Ports, []types.ServicePortConfig{
+		{Mode: "ingress", Target: 90, Published: "9090", Protocol: "tcp"},
+	})
+}
+
+func TestLoadWithInvalidResetSequenceItem(t *testing.T) {
+	base := `
+name: load-invalid-reset-item
+services:
+  foo:
+    image: alpine
+    environment:
+      FOO: foo
+`
+	override := `
+services:
+  foo:
+    environment:
+      - !reset {FOO: bar}
+`
+	_, err := Load(buildConfigDetailsMultipleFiles(nil, base, override))
+	assert.ErrorContains(t, err, "validating filename1.yml: services.foo.environment.0 must be a string")
+
+	_, err = Load(buildConfigDetailsMultipleFiles(nil, base, override), func(options *Options) {
+		options.SkipValidation = true
+	})
+	assert.ErrorContains(t, err, "unsupported environment value map[FOO:bar]")
+}
+
+func TestLoadExtendsWithInvalidResetSequenceItem(t *testing.T) {
+	_, err := Load(buildConfigDetails(`
+name: load-extends-invalid-reset-item
+services:
+  foo:
+    extends:
+      service: bar
+    environment:
+      - !reset {FOO: bar}
+  bar:
+    image: alpine
+    environment:
+      FOO: foo
+`, nil), func(options *Options) {
+		options.SkipValidation = true
+	})
+	assert.ErrorContains(t, err, "unsupported environment value map[FOO:bar]")
+}
+
 func TestLoadCredentialSpec(t *testing.T) {
 	actual, err := loadYAML(`
 name: load-credential-spec
@@ -456,9 +662,9 @@ services:
 func TestParseAndLoad(t *testing.T) {
 	actual, err := loadYAML(sampleYAML)
 	assert.NilError(t, err)
-	assert.Check(t, is.DeepEqual(sampleConfig.Services, actual.Services))
-	assert.Check(t, is.DeepEqual(sampleConfig.Networks, actual.Networks))
-	assert.Check(t, is.DeepEqual(sampleConfig.Volumes, actual.Volumes))
+	assert.Check(t, is.DeepEqual(withOrigin(sampleConfig.Services, "filename0.yml"), actual.Services))
+	assert.Check(t, is.DeepEqual(withOrigin(sampleConfig.Networks, "filename0.yml"), actual.Networks))
+	assert.Check(t, is.DeepEqual(withOrigin(sampleConfig.Volumes, "filename0.yml"), actual.Volumes))
 }
 
 func TestInvalidTopLevelObjectType(t *testing.T) {
@@ -854,6 +1060,7 @@ networks:
 					},
 				},
 				Environment: types.MappingWithEquals{},
+				Origin:      "filename0.yml",
 			},
 		},
 		Configs: map[string]types.ConfigObjConfig{
@@ -863,19 +1070,20 @@ networks:
 			"super": {External: true},
 		},
 		Volumes: map[string]types.VolumeConfig{
-			"data": {External: true},
+			"data": {External: true, Origin: "filename0.yml"},
 		},
 		Networks: map[string]types.NetworkConfig{
-			"back": {},
+			"back": {Origin: "filename0.yml"},
 			"front": {
 				External:   true,
 				Internal:   true,
 				Attachable: true,
+				Origin:     "filename0.yml",
 			},
 		},
 	}
 
-	assert.DeepEqual(t, expected, config)
+	assert.DeepEqual(t, expected, config, cmpopts.IgnoreFields(types.Project{}, "InputFiles"))
 }
 
 func TestUnsupportedProperties(t *testing.T) {
@@ -1089,9 +1297,9 @@ func TestFullExample(t *testing.T) {
 	expectedConfig := fullExampleProject(workingDir, homeDir)
 
 	assert.Check(t, is.DeepEqual(expectedConfig.Name, config.Name))
-	assert.Check(t, is.DeepEqual(expectedConfig.Services, config.Services))
-	assert.Check(t, is.DeepEqual(expectedConfig.Networks, config.Networks))
-	assert.Check(t, is.DeepEqual(expectedConfig.Volumes, config.Volumes))
+	assert.Check(t, is.DeepEqual(withOrigin(expectedConfig.Services, "filename0.yml"), config.Services))
+	assert.Check(t, is.DeepEqual(withOrigin(expectedConfig.Networks, "filename0.yml"), config.Networks))
+	assert.Check(t, is.DeepEqual(withOrigin(expectedConfig.Volumes, "filename0.yml"), config.Volumes))
 	assert.Check(t, is.DeepEqual(expectedConfig.Secrets, config.Secrets))
 	assert.Check(t, is.DeepEqual(expectedConfig.Configs, config.Configs))
 	assert.Check(t, is.DeepEqual(expectedConfig.Extensions, config.Extensions))
@@ -1327,10 +1535,12 @@ networks:
 		"mynet1": {
 			Driver:     "overlay",
 			Attachable: true,
+			Origin:     "filename0.yml",
 		},
 		"mynet2": {
 			Driver:     "bridge",
 			Attachable: false,
+			Origin:     "filename0.yml",
 		},
 	}
 
@@ -1447,12 +1657,56 @@ volumes:
 		"foo": {
 			Name:     "oops",
 			External: true,
+			Origin:   "filename0.yml",
 		},
 	}
 	assert.Check(t, is.DeepEqual(expected, project.Volumes))
 	assert.Check(t, is.Contains(buf.String(), "volumes.foo: external.name is deprecated. Please set name and external: true"))
 }
 
+func TestLoadDiagnostics(t *testing.T) {
+	collector := &diagnostics.Collector{}
+	details := buildConfigDetailsMultipleFiles(nil, `
+name: test-diagnostics
+services:
+  foo:
+    image: foo
+    log_driver: syslog
+    privileged: yes
+`, `
+volumes:
+  data:
+    external:
+      name: oops
+`)
+	_, err := LoadWithContext(context.Background(), details, func(options *Options) {
+		// log_driver is not part of the schema
+		options.SkipValidation = true
+		options.Diagnostics = collector
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, collector.Diagnostics(), []diagnostics.Diagnostic{
+		{
+			Code:    diagnostics.Deprecated,
+			Message: "\"yes\" for boolean is not supported by YAML 1.2, please use `true`",
+			Path:    "services.foo.privileged",
+			File:    "filename0.yml",
+		},
+		{
+			Code:    diagnostics.Deprecated,
+			Message: "external.name is deprecated. Please set name and external: true",
+			Path:    "volumes.data",
+			File:    "filename1.yml",
+		},
+		{
+			Code:    diagnostics.Deprecated,
+			Message: "`log_driver` is deprecated. Use the `logging` element",
+			Path:    "services.foo.log_driver",
+			File:    "filename0.yml",
+		},
+	})
+}
+
 func patchLogrus() (*bytes.Buffer, func()) {
 	buf := new(bytes.Buffer)
 	out := logrus.StandardLogger().Out
@@ -1529,6 +1783,7 @@ networks:
 		"foo": {
 			Name:     "oops",
 			External: true,
+			Origin:   "filename0.yml",
 		},
 	}
 	assert.Check(t, is.DeepEqual(expected, project.Networks))
@@ -1579,17 +1834,18 @@ networks:
 					"network1": nil,
 					"network3": nil,
 				},
+				Origin: "filename0.yml",
 			},
 		},
 		Networks: map[string]types.NetworkConfig{
-			"network1": {Name: "network2"},
-			"network3": {},
+			"network1": {Name: "network2", Origin: "filename0.yml"},
+			"network3": {Origin: "filename0.yml"},
 		},
 		Environment: types.Mapping{
 			"COMPOSE_PROJECT_NAME": "load-network-with-name",
 		},
 	}
-	assert.DeepEqual(t, config, expected, cmpopts.EquateEmpty())
+	assert.DeepEqual(t, config, expected, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(types.Project{}, "InputFiles"))
 }
 
 func TestLoadNetworkLinkLocalIPs(t *testing.T) {
@@ -1634,6 +1890,7 @@ networks:
 						},
 					},
 				},
+				Origin: "filename0.yml",
 			},
 		},
 		Networks: map[string]types.NetworkConfig{
@@ -1647,13 +1904,14 @@ networks:
 						{Subnet: "2001:db8:0:1::/64"},
 					},
 				},
+				Origin: "filename0.yml",
 			},
 		},
 		Environment: types.Mapping{
 			"COMPOSE_PROJECT_NAME": "load-network-link-local-ips",
 		},
 	}
-	assert.DeepEqual(t, config, expected, cmpopts.EquateEmpty())
+	assert.DeepEqual(t, config, expected, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(types.Project{}, "InputFiles"))
 }
 
 func TestLoadInit(t *testing.T) {
@@ -1791,6 +2049,7 @@ secrets:
 						Source: "secret",
 					},
 				},
+				Origin: "filename0.yml",
 			},
 		},
 		Configs: map[string]types.ConfigObjConfig{
@@ -1811,7 +2070,7 @@ secrets:
 			"COMPOSE_PROJECT_NAME": "load-template-driver",
 		},
 	}
-	assert.DeepEqual(t, config, expected, cmpopts.EquateEmpty())
+	assert.DeepEqual(t, config, expected, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(types.Project{}, "InputFiles"))
 }
 
 func TestLoadSecretDriver(t *testing.T) {
@@ -1860,6 +2119,7 @@ secrets:
 						Source: "secret",
 					},
 				},
+				Origin: "filename0.yml",
 			},
 		},
 		Configs: map[string]types.ConfigObjConfig{
@@ -1882,7 +2142,7 @@ secrets:
 			"COMPOSE_PROJECT_NAME": "load-secret-driver",
 		},
 	}
-	assert.DeepEqual(t, config, expected, cmpopts.EquateEmpty())
+	assert.DeepEqual(t, config, expected, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(types.Project{}, "InputFiles"))
 }
 
 func TestComposeFileWithVersion(t *testing.T) {
@@ -1897,9 +2157,9 @@ func TestComposeFileWithVersion(t *testing.T) {
 
 	expectedConfig := withVersionExampleConfig()
 
-	assert.Check(t, is.DeepEqual(expectedConfig.Services, config.Services))
-	assert.Check(t, is.DeepEqual(expectedConfig.Networks, config.Networks))
-	assert.Check(t, is.DeepEqual(expectedConfig.Volumes, config.Volumes))
+	assert.Check(t, is.DeepEqual(withOrigin(expectedConfig.Services, "filename0.yml"), config.Services))
+	assert.Check(t, is.DeepEqual(withOrigin(expectedConfig.Networks, "filename0.yml"), config.Networks))
+	assert.Check(t, is.DeepEqual(withOrigin(expectedConfig.Volumes, "filename0.yml"), config.Volumes))
 }
 
 func TestLoadWithExtends(t *testing.T) {
@@ -1937,6 +2197,7 @@ func TestLoadWithExtends(t *testing.T) {
 				Target: "/var/lib/mysql",
 				Bind:   &types.ServiceVolumeBind{CreateHostPath: true},
 			}},
+			Origin: "compose-test-extends.yaml",
 		},
 	}
 	assert.Check(t, is.DeepEqual(expServices, actual.Services))
@@ -1966,6 +2227,7 @@ func TestLoadWithExtendsWithContextUrl(t *testing.T) {
 			},
 			Environment: types.MappingWithEquals{},
 			Networks:    map[string]*types.ServiceNetworkConfig{"default": nil},
+			Origin:      "testdata/compose-test-extends-with-context-url.yaml",
 		},
 	}
 	assert.Check(t, is.DeepEqual(expServices, actual.Services))
@@ -2311,6 +2573,165 @@ services:
 	assert.Equal(t, project.Services["extension"].Extensions["x-foo"], "bar")
 }
 
+type traefik struct {
+	Rule    string          `yaml:"rule"`
+	Port    int             `yaml:"port"`
+	Timeout types.Duration  `yaml:"timeout"`
+	Memory  types.UnitBytes `yaml:"memory"`
+}
+
+func TestLoadKnownExtensions(t *testing.T) {
+	registered := extensionTypes.Clone()
+	defer func() {
+		extensionTypes = registered
+	}()
+	assert.NilError(t, RegisterExtension("services.*.x-traefik", traefik{}))
+
+	dict := `
+name: test
+services:
+  web:
+    image: web
+    x-traefik:
+      rule: Host(web)
+      port: ${PORT}
+      timeout: 10s
+      memory: 64m
+    x-foo: bar
+x-traefik:
+  rule: Host(web)
+`
+	project, err := Load(buildConfigDetails(dict, map[string]string{"PORT": "8080"}))
+	assert.NilError(t, err)
+	expected := traefik{
+		Rule:    "Host(web)",
+		Port:    8080,
+		Timeout: types.Duration(10 * time.Second),
+		Memory:  64 * 1024 * 1024,
+	}
+	web := project.Services["web"]
+	assert.DeepEqual(t, web.Extensions["x-traefik"], expected)
+	assert.Equal(t, web.Extensions["x-foo"], "bar")
+
+	var actual traefik
+	ok, err := web.Extensions.Get("x-traefik", &actual)
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+	assert.DeepEqual(t, actual, expected)
+	// extension is only decoded at registered path
+	assert.DeepEqual(t, project.Extensions["x-traefik"], map[string]any{"rule": "Host(web)"})
+
+	_, err = Load(buildConfigDetails(dict, map[string]string{"PORT": "http"}))
+	assert.ErrorContains(t, err, "services.web.x-traefik: ")
+	assert.ErrorContains(t, err, "port")
+}
+
+func TestRegisterExtensionInvalid(t *testing.T) {
+	err := RegisterExtension("services.*.traefik", traefik{})
+	assert.ErrorContains(t, err, "must end with an x- attribute")
+
+	err = RegisterExtension("services.*.x-traefik", nil)
+	assert.ErrorContains(t, err, "a sample value is required")
+}
+
+func TestLoadExtensionOverride(t *testing.T) {
+	// registries can't be reset, so test uses an extension name dedicated to this test
+	override.RegisterMergeRule("services.*.x-merged", func(base any, o any, _ tree.Path) (any, error) {
+		merged := map[string]any{}
+		for k, v := range base.(map[string]any) {
+			merged[k] = v
+		}
+		for k, v := range o.(map[string]any) {
+			merged[k] = v
+		}
+		return merged, nil
+	})
+	assert.NilError(t, schema.RegisterExtension("services.*.x-merged", `{
+  "type": "object",
+  "properties": {
+    "port": {"type": "integer"},
+    "rule": {"type": "string"}
+  },
+  "required": ["rule"]
+}`))
+
+	base := `
+name: test
+services:
+  web:
+    image: web
+    x-merged:
+      rule: Host(web)
+`
+	// override only sets part of the extension, which is valid once merged
+	project, err := Load(buildConfigDetailsMultipleFiles(nil, base, `
+services:
+  web:
+    x-merged:
+      port: 8080
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, project.Services["web"].Extensions["x-merged"], map[string]any{"rule": "Host(web)", "port": 8080})
+
+	_, err = Load(buildConfigDetailsMultipleFiles(nil, base, `
+services:
+  web:
+    x-merged:
+      port: http
+`))
+	assert.Error(t, err, "services.web.x-merged.port must be a integer")
+}
+
+func TestLoadTargetVersion(t *testing.T) {
+	dict := `
+name: test
+services:
+  web:
+    image: web
+    healthcheck:
+      test: ["CMD", "true"]
+      start_interval: 1s
+`
+	_, err := Load(buildConfigDetails(dict, nil), func(options *Options) {
+		options.TargetVersion = "2.20.0"
+	})
+	assert.Check(t, errdefs.IsUnsupportedError(err))
+	assert.ErrorContains(t, err, "services.web.healthcheck.start_interval requires version 2.20.2")
+
+	_, err = Load(buildConfigDetails(dict, nil), func(options *Options) {
+		options.TargetVersion = "2.20.2"
+	})
+	assert.NilError(t, err)
+}
+
+func TestLoadTargetVersionTags(t *testing.T) {
+	details := buildConfigDetailsMultipleFiles(nil, `
+name: test
+services:
+  web:
+    image: web
+    ports:
+      - 8080:80
+`, `
+services:
+  web:
+    ports: !override
+      - 9090:80
+`)
+	_, err := Load(details, func(options *Options) {
+		options.TargetVersion = "2.24.0"
+	})
+	assert.Check(t, errdefs.IsUnsupportedError(err))
+	assert.ErrorContains(t, err, "validating filename1.yml: target version 2.24.0 doesn't support:\n"+
+		"!override services.web.ports requires version 2.24.4")
+
+	project, err := Load(details, func(options *Options) {
+		options.TargetVersion = "2.24.4"
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, project.Services["web"].Ports[0].Published, "9090")
+}
+
 func TestDeviceWriteBps(t *testing.T) {
 	p, err := loadYAML(`
         name: test
@@ -2345,6 +2766,7 @@ func TestDeviceWriteBps(t *testing.T) {
 					},
 				},
 			},
+			Origin: "filename0.yml",
 		},
 	})
 
@@ -2382,6 +2804,7 @@ volumes:
 					Volume: &types.ServiceVolumeVolume{},
 				},
 			},
+			Origin: "filename0.yml",
 		},
 	})
 }
@@ -2445,9 +2868,11 @@ services:
 			Image:       "busybox",
 			Environment: types.MappingWithEquals{},
 			DependsOn:   types.DependsOnConfig{"imported": {Condition: "service_started", Required: true}},
+			Origin:      "filename0.yml",
 		},
 		"imported": {
 			Name:          "imported",
+			Origin:        filepath.Join(workingDir, "testdata", "subdir", "compose-test-extends-imported.yaml"),
 			ContainerName: "extends", // as defined by ./testdata/subdir/extra.env
 			Environment:   types.MappingWithEquals{"SOURCE": strPtr("extends")},
 			EnvFile: types.StringList{
@@ -2464,17 +2889,15 @@ services:
 			},
 		},
 	})
-	/* TODO(ndeloof) restore support for include tracking
 	assert.DeepEqual(t, p.IncludeReferences, map[string][]types.IncludeConfig{
-		filepath.Join(workingDir, "filename0.yml"): {
+		"filename0.yml": {
 			{
 				Path:             []string{filepath.Join(workingDir, "testdata", "subdir", "compose-test-extends-imported.yaml")},
-				ProjectDirectory: workingDir,
+				ProjectDirectory: filepath.Join(workingDir, "testdata", "subdir"),
 				EnvFile:          []string{filepath.Join(workingDir, "testdata", "subdir", "extra.env")},
 			},
 		},
 	})
-	*/
 
 	p, err = Load(buildConfigDetails(`
 name: 'test-include'
@@ -2498,6 +2921,210 @@ services:
 	assert.Equal(t, imported.ContainerName, "override")
 }
 
+func TestLoadListeners(t *testing.T) {
+	workingDir, err := os.Getwd()
+	assert.NilError(t, err)
+	events := map[string][]any{}
+	_, err = Load(buildConfigDetails(`
+name: 'test-listeners'
+
+include:
+  - path: ./testdata/subdir/compose-test-extends-imported.yaml
+    env_file: ./testdata/subdir/extra.env
+
+services:
+  foo:
+    extends:
+      file: ./testdata/subdir/compose-test-extends-imported.yaml
+      service: imported
+`, nil), func(options *Options) {
+		options.Listeners = append(options.Listeners, func(event string, metadata map[string]any) {
+			switch event {
+			case EventLoad, EventEnvFile:
+				events[event] = append(events[event], metadata["file"])
+			case EventInclude:
+				events[event] = append(events[event], metadata["path"])
+			}
+		})
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, map[string][]any{
+		EventLoad: {
+			"filename0.yml",
+			filepath.Join(workingDir, "testdata", "subdir", "compose-test-extends-imported.yaml"),
+			filepath.Join(workingDir, "testdata", "subdir", "compose-test-extends-imported.yaml"),
+		},
+		EventInclude: {
+			types.StringList{filepath.Join(workingDir, "testdata", "subdir", "compose-test-extends-imported.yaml")},
+		},
+		EventEnvFile: {
+			filepath.Join(workingDir, "testdata", "subdir", "extra.env"),
+			filepath.Join(workingDir, "testdata", "subdir", "extra.env"),
+		},
+	})
+}
+
+func TestLoadInputFiles(t *testing.T) {
+	workingDir, err := os.Getwd()
+	assert.NilError(t, err)
+	imported := filepath.Join(workingDir, "testdata", "subdir", "compose-test-extends-imported.yaml")
+	extraEnv := filepath.Join(workingDir, "testdata", "subdir", "extra.env")
+	dockerfile := filepath.Join(workingDir, "testdata", "Dockerfile")
+	p, err := Load(buildConfigDetails(`
+name: 'test-input-files'
+
+include:
+  - path: ./testdata/subdir/compose-test-extends-imported.yaml
+    env_file: ./testdata/subdir/extra.env
+
+services:
+  foo:
+    extends:
+      file: ./testdata/subdir/compose-test-extends-imported.yaml
+      service: imported
+secrets:
+  key:
+    file: ./testdata/Dockerfile
+`, nil))
+	assert.NilError(t, err)
+
+	digest := func(kind types.InputFileKind, path string) types.InputFile {
+		f, err := types.ReadInputFile(kind, path)
+		assert.NilError(t, err)
+		return f
+	}
+	content := buildConfigFiles([]string{""})[0]
+	assert.Equal(t, p.InputFiles[0].Path, content.Filename)
+	assert.Equal(t, p.InputFiles[0].Kind, types.InputFileCompose)
+	assert.DeepEqual(t, p.InputFiles[1:], []types.InputFile{
+		digest(types.InputFileExtends, imported),
+		digest(types.InputFileDotEnv, extraEnv),
+		digest(types.InputFileInclude, imported),
+		digest(types.InputFileEnvFile, extraEnv),
+		digest(types.InputFileSecret, dockerfile),
+	})
+
+	same, err := Load(buildConfigDetails(`
+name: 'test-input-files'
+services:
+  foo:
+    image: nginx
+`, nil))
+	assert.NilError(t, err)
+	assert.Check(t, same.InputFilesDigest() != p.InputFilesDigest())
+}
+
+func TestLoadInputFilesInMemoryConfig(t *testing.T) {
+	model := map[string]any{
+		"name": "test-input-files",
+		"services": map[string]any{
+			"foo": map[string]any{"image": "nginx"},
+		},
+	}
+	p, err := Load(types.ConfigDetails{
+		WorkingDir:  t.TempDir(),
+		ConfigFiles: []types.ConfigFile{{Filename: "compose.yaml", Config: model}},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(p.InputFiles), 1)
+	assert.Equal(t, p.InputFiles[0].Path, "compose.yaml")
+	assert.Equal(t, p.InputFiles[0].Kind, types.InputFileCompose)
+}
+
+func TestLoadInputFilesReadError(t *testing.T) {
+	p, err := Load(buildConfigDetails(`
+name: 'test-input-files'
+services:
+  foo:
+    image: nginx
+secrets:
+  key:
+    file: ./testdata
+`, nil))
+	assert.NilError(t, err)
+	assert.Equal(t, len(p.InputFiles), 1)
+	assert.Equal(t, p.InputFiles[0].Kind, types.InputFileCompose)
+}
+
+func TestLoadWithNestedInclude(t *testing.T) {
+	workingDir, err := os.Getwd()
+	assert.NilError(t, err)
+	include := filepath.Join(workingDir, "testdata", "compose-include.yaml")
+	imported := filepath.Join(workingDir, "testdata", "subdir", "compose-test-extends-imported.yaml")
+	p, err := Load(buildConfigDetails(`
+name: 'test-nested-include'
+
+include:
+  - path: ./testdata/compose-include.yaml
+    project_directory: ./testdata
+
+services:
+  foo:
+    image: busybox
+`, nil))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, p.IncludeReferences, map[string][]types.IncludeConfig{
+		"filename0.yml": {
+			{
+				Path:             []string{include},
+				ProjectDirectory: filepath.Join(workingDir, "testdata"),
+			},
+		},
+		include: {
+			{
+				Path:             []string{imported},
+				ProjectDirectory: filepath.Join(workingDir, "testdata", "subdir"),
+			},
+		},
+	})
+	assert.Equal(t, p.Services["foo"].Origin, "filename0.yml")
+	assert.Equal(t, p.Services["bar"].Origin, include)
+	assert.Equal(t, p.Services["imported"].Origin, imported)
+}
+
+func TestLoadWithIncludeInOverride(t *testing.T) {
+	workingDir, err := os.Getwd()
+	assert.NilError(t, err)
+	imported := filepath.Join(workingDir, "testdata", "subdir", "compose-test-extends-imported.yaml")
+	p, err := Load(buildConfigDetailsMultipleFiles(nil, `
+name: 'test-include-in-override'
+services:
+  foo:
+    image: busybox
+networks:
+  front: {}
+`, `
+include:
+  - path: ./testdata/subdir/compose-test-extends-imported.yaml
+    env_file: ./testdata/subdir/extra.env
+services:
+  foo:
+    image: nginx
+  bar:
+    image: busybox
+volumes:
+  data: {}
+`), func(options *Options) {
+		options.SkipNormalization = true
+		options.ResolvePaths = true
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, p.IncludeReferences, map[string][]types.IncludeConfig{
+		"filename1.yml": {
+			{
+				Path:             []string{imported},
+				ProjectDirectory: filepath.Join(workingDir, "testdata", "subdir"),
+				EnvFile:          []string{filepath.Join(workingDir, "testdata", "subdir", "extra.env")},
+			},
+		},
+	})
+	assert.Equal(t, p.Services["foo"].Origin, "filename0.yml")
+	assert.Equal(t, p.Services["bar"].Origin, "filename1.yml")
+	assert.Equal(t, p.Services["imported"].Origin, imported)
+	assert.Equal(t, p.Networks["front"].Origin, "filename0.yml")
+	assert.Equal(t, p.Volumes["data"].Origin, "filename1.yml")
+}
+
 func TestLoadWithIncludeCycle(t *testing.T) {
 
 	workingDir, err := os.Getwd()
@@ -2585,6 +3212,7 @@ services:
 				"baz": {Condition: types.ServiceConditionHealthy, Required: false},
 				"qux": {Condition: types.ServiceConditionCompletedSuccessfully, Required: true},
 			},
+			Origin: "filename0.yml",
 		},
 	})
 }
@@ -2641,6 +3269,7 @@ services:
 					Bind:   &types.ServiceVolumeBind{CreateHostPath: true},
 				},
 			},
+			Origin: "filename0.yml",
 		},
 	})
 }
@@ -2860,3 +3489,55 @@ services:
 		"nofile": {Soft: 20000, Hard: 40000},
 	})
 }
+
+func TestUnmerge(t *testing.T) {
+	base := `
+name: unmerge
+services:
+  web:
+    image: nginx
+    ports:
+      - 8080:80
+      - 9090:90
+    environment:
+      FOO: foo
+      BAR: bar
+    volumes:
+      - data:/data
+  db:
+    image: postgres
+volumes:
+  data: {}
+`
+	loadOptions := func(options *Options) {
+		options.SkipNormalization = true
+	}
+	p, err := Load(buildConfigDetails(base, nil), loadOptions)
+	assert.NilError(t, err)
+
+	web := p.Services["web"]
+	web.Image = "nginx:alpine"
+	web.Ports = web.Ports[:1]
+	web.Environment = types.MappingWithEquals{"FOO": strPtr("foo"), "ZOT": strPtr("zot")}
+	p.Services["web"] = web
+	delete(p.Services, "db")
+	p.Volumes["cache"] = types.VolumeConfig{Name: "unmerge_cache"}
+
+	override, err := Unmerge(&types.Project{}, &types.Project{})
+	assert.NilError(t, err)
+	assert.Check(t, override == nil)
+
+	original, err := Load(buildConfigDetails(base, nil), loadOptions)
+	assert.NilError(t, err)
+	override, err = Unmerge(original, p)
+	assert.NilError(t, err)
+
+	actual, err := Load(buildConfigDetailsMultipleFiles(nil, base, string(override)), loadOptions)
+	assert.NilError(t, err)
+	// cache volume is declared by override file
+	cache := p.Volumes["cache"]
+	cache.Origin = "filename1.yml"
+	p.Volumes["cache"] = cache
+	assert.DeepEqual(t, actual.Services, p.Services)
+	assert.DeepEqual(t, actual.Volumes, p.Volumes)
+}
@@ -19,6 +19,7 @@ package loader
 import (
 	"testing"
 
+	"github.com/compose-spec/compose-go/v2/internal/decode"
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/mitchellh/mapstructure"
 	"gotest.tools/v3/assert"
@@ -31,7 +32,7 @@ func TestDecodeMapStructure(t *testing.T) {
 		Result:     &target,
 		TagName:    "yaml",
 		Metadata:   &data,
-		DecodeHook: mapstructure.ComposeDecodeHookFunc(decoderHook),
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(decode.Hook),
 	}
 	decoder, err := mapstructure.NewDecoder(config)
 	assert.NilError(t, err)
@@ -18,16 +18,22 @@ package loader
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/compose-spec/compose-go/v2/diagnostics"
 	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 )
 
 // Normalize compose project by moving deprecated attributes to their canonical position and injecting implicit defaults
 func Normalize(project *types.Project) error {
+	return normalize(project, diagnostics.Logrus)
+}
+
+func normalize(project *types.Project, sink diagnostics.Sink) error {
 	if project.Networks == nil {
 		project.Networks = make(map[string]types.NetworkConfig)
 	}
@@ -35,12 +41,14 @@ func Normalize(project *types.Project) error {
 	// If not declared explicitly, Compose model involves an implicit "default" network
 	if _, ok := project.Networks["default"]; !ok {
 		project.Networks["default"] = types.NetworkConfig{}
+		project.AddImplicitDefault(tree.NewPath("networks", "default"))
 	}
 
 	for name, s := range project.Services {
 		if len(s.Networks) == 0 && s.NetworkMode == "" {
 			// Service without explicit network attachment are implicitly exposed on default network
 			s.Networks = map[string]*types.ServiceNetworkConfig{"default": nil}
+			project.AddImplicitDefault(tree.NewPath("services", name, "networks", "default"))
 		}
 
 		if s.PullPolicy == types.PullPolicyIfNotPresent {
@@ -55,9 +63,11 @@ func Normalize(project *types.Project) error {
 		if s.Build != nil {
 			if s.Build.Context == "" {
 				s.Build.Context = "."
+				project.AddImplicitDefault(tree.NewPath("services", name, "build", "context"))
 			}
 			if s.Build.Dockerfile == "" && s.Build.DockerfileInline == "" {
 				s.Build.Dockerfile = "Dockerfile"
+				project.AddImplicitDefault(tree.NewPath("services", name, "build", "dockerfile"))
 			}
 			s.Build.Args = s.Build.Args.Resolve(fn)
 		}
@@ -68,7 +78,7 @@ func Normalize(project *types.Project) error {
 			if len(parts) == 2 {
 				link = parts[0]
 			}
-			s.DependsOn = setIfMissing(s.DependsOn, link, types.ServiceDependency{
+			s.DependsOn = setIfMissing(project, name, s.DependsOn, link, types.ServiceDependency{
 				Condition: types.ServiceConditionStarted,
 				Restart:   true,
 				Required:  true,
@@ -77,8 +87,8 @@ func Normalize(project *types.Project) error {
 
 		for _, namespace := range []string{s.NetworkMode, s.Ipc, s.Pid, s.Uts, s.Cgroup} {
 			if strings.HasPrefix(namespace, types.ServicePrefix) {
-				name := namespace[len(types.ServicePrefix):]
-				s.DependsOn = setIfMissing(s.DependsOn, name, types.ServiceDependency{
+				dependency := namespace[len(types.ServicePrefix):]
+				s.DependsOn = setIfMissing(project, name, s.DependsOn, dependency, types.ServiceDependency{
 					Condition: types.ServiceConditionStarted,
 					Restart:   true,
 					Required:  true,
@@ -89,7 +99,7 @@ func Normalize(project *types.Project) error {
 		for _, vol := range s.VolumesFrom {
 			if !strings.HasPrefix(vol, types.ContainerPrefix) {
 				spec := strings.Split(vol, ":")
-				s.DependsOn = setIfMissing(s.DependsOn, spec[0], types.ServiceDependency{
+				s.DependsOn = setIfMissing(project, name, s.DependsOn, spec[0], types.ServiceDependency{
 					Condition: types.ServiceConditionStarted,
 					Restart:   false,
 					Required:  true,
@@ -97,22 +107,22 @@ func Normalize(project *types.Project) error {
 			}
 		}
 
-		err := relocateLogDriver(&s)
+		err := relocateLogDriver(name, &s, sink)
 		if err != nil {
 			return err
 		}
 
-		err = relocateLogOpt(&s)
+		err = relocateLogOpt(name, &s, sink)
 		if err != nil {
 			return err
 		}
 
-		err = relocateDockerfile(&s)
+		err = relocateDockerfile(name, &s, sink)
 		if err != nil {
 			return err
 		}
 
-		inferImplicitDependencies(&s)
+		inferImplicitDependencies(project, name, &s)
 
 		project.Services[name] = s
 	}
@@ -133,7 +143,7 @@ func IsServiceDependency(ref string) (string, bool) {
 	return "", false
 }
 
-func inferImplicitDependencies(service *types.ServiceConfig) {
+func inferImplicitDependencies(project *types.Project, name string, service *types.ServiceConfig) {
 	var dependencies []string
 
 	maybeReferences := []string{
@@ -174,17 +184,19 @@ func inferImplicitDependencies(service *types.ServiceConfig) {
 				Condition: types.ServiceConditionStarted,
 				Required:  true,
 			}
+			project.AddImplicitDefault(tree.NewPath("services", name, "depends_on", d))
 		}
 	}
 }
 
-// setIfMissing adds a ServiceDependency for service if not already defined
-func setIfMissing(d types.DependsOnConfig, service string, dep types.ServiceDependency) types.DependsOnConfig {
+// setIfMissing adds a ServiceDependency for service if not already defined, and records it as implicit for dependent
+func setIfMissing(project *types.Project, dependent string, d types.DependsOnConfig, service string, dep types.ServiceDependency) types.DependsOnConfig {
 	if d == nil {
 		d = types.DependsOnConfig{}
 	}
 	if _, ok := d[service]; !ok {
 		d[service] = dep
+		project.AddImplicitDefault(tree.NewPath("services", dependent, "depends_on", service))
 	}
 	return d
 }
@@ -236,12 +248,20 @@ func setNameFromKey(project *types.Project) {
 	}
 }
 
-func relocateLogOpt(s *types.ServiceConfig) error {
+func relocateLogOpt(name string, s *types.ServiceConfig, sink diagnostics.Sink) error {
 	if len(s.LogOpt) != 0 {
-		logrus.Warn("`log_opts` is deprecated. Use the `logging` element")
+		sink.Report(diagnostics.Diagnostic{
+			Code:    diagnostics.Deprecated,
+			Message: "`log_opts` is deprecated. Use the `logging` element",
+			Path:    tree.NewPath("services", name, "log_opt"),
+			File:    s.Origin,
+		})
 		if s.Logging == nil {
 			s.Logging = &types.LoggingConfig{}
 		}
+		if s.Logging.Options == nil {
+			s.Logging.Options = map[string]string{}
+		}
 		for k, v := range s.LogOpt {
 			if _, ok := s.Logging.Options[k]; !ok {
 				s.Logging.Options[k] = v
@@ -253,9 +273,14 @@ func relocateLogOpt(s *types.ServiceConfig) error {
 	return nil
 }
 
-func relocateLogDriver(s *types.ServiceConfig) error {
+func relocateLogDriver(name string, s *types.ServiceConfig, sink diagnostics.Sink) error {
 	if s.LogDriver != "" {
-		logrus.Warn("`log_driver` is deprecated. Use the `logging` element")
+		sink.Report(diagnostics.Diagnostic{
+			Code:    diagnostics.Deprecated,
+			Message: "`log_driver` is deprecated. Use the `logging` element",
+			Path:    tree.NewPath("services", name, "log_driver"),
+			File:    s.Origin,
+		})
 		if s.Logging == nil {
 			s.Logging = &types.LoggingConfig{}
 		}
@@ -268,9 +293,14 @@ func relocateLogDriver(s *types.ServiceConfig) error {
 	return nil
 }
 
-func relocateDockerfile(s *types.ServiceConfig) error {
+func relocateDockerfile(name string, s *types.ServiceConfig, sink diagnostics.Sink) error {
 	if s.Dockerfile != "" {
-		logrus.Warn("`dockerfile` is deprecated. Use the `build` element")
+		sink.Report(diagnostics.Diagnostic{
+			Code:    diagnostics.Deprecated,
+			Message: "`dockerfile` is deprecated. Use the `build` element",
+			Path:    tree.NewPath("services", name, "dockerfile"),
+			File:    s.Origin,
+		})
 		if s.Build == nil {
 			s.Build = &types.BuildConfig{}
 		}
@@ -282,3 +312,27 @@ func relocateDockerfile(s *types.ServiceConfig) error {
 	}
 	return nil
 }
+
+// reportExternalName reports resources using deprecated `external.name`, which transform.Canonical relocates to `name`
+func reportExternalName(model map[string]any, sink diagnostics.Sink) {
+	for _, resource := range []string{"networks", "volumes", "secrets", "configs"} {
+		declared, _ := model[resource].(map[string]any)
+		names := make([]string, 0, len(declared))
+		for name := range declared {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			config, _ := declared[name].(map[string]any)
+			if external, ok := config["external"].(map[string]any); ok {
+				if _, ok := external["name"]; ok {
+					sink.Report(diagnostics.Diagnostic{
+						Code:    diagnostics.Deprecated,
+						Message: "external.name is deprecated. Please set name and external: true",
+						Path:    tree.NewPath(resource, name),
+					})
+				}
+			}
+		}
+	}
+}
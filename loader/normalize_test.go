@@ -20,6 +20,8 @@ import (
 	"os"
 	"testing"
 
+	"github.com/compose-spec/compose-go/v2/diagnostics"
+	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/compose-spec/compose-go/v2/types"
 	"gotest.tools/v3/assert"
 )
@@ -114,6 +116,7 @@ func TestNormalizeVolumes(t *testing.T) {
 				Name: "CustomName",
 			},
 		},
+		ImplicitDefaults: []tree.Path{"networks.default"},
 	}
 	err := Normalize(&project)
 	assert.NilError(t, err)
@@ -232,3 +235,90 @@ func TestImplicitContextPath(t *testing.T) {
 	assert.NilError(t, Normalize(project))
 	assert.Equal(t, ".", project.Services["test"].Build.Context)
 }
+
+func TestDenormalize(t *testing.T) {
+	load := func(yaml string) (*types.Project, error) {
+		return Load(buildConfigDetails(yaml, nil), func(options *Options) {
+			options.SkipConsistencyCheck = true
+		})
+	}
+	p, err := load(`
+name: denormalize
+services:
+  web:
+    build:
+      args:
+        FOO: bar
+    links:
+      - db
+    depends_on:
+      cache:
+        condition: service_healthy
+  db:
+    image: postgres
+    networks:
+      - back
+  cache:
+    image: redis
+    network_mode: service:db
+    volumes_from:
+      - db
+networks:
+  back: {}
+`)
+	assert.NilError(t, err)
+	assert.Check(t, p.IsImplicitDefault("networks.default"))
+	assert.Check(t, p.IsImplicitDefault("services.web.build.context"))
+	assert.Check(t, p.IsImplicitDefault("services.web.depends_on.db"))
+	assert.Check(t, !p.IsImplicitDefault("services.web.depends_on.cache"))
+
+	p.Denormalize()
+	assert.Check(t, p.ImplicitDefaults == nil)
+	_, ok := p.Networks["default"]
+	assert.Check(t, !ok)
+	web := p.Services["web"]
+	assert.Equal(t, web.Build.Context, "")
+	assert.Equal(t, web.Build.Dockerfile, "")
+	assert.Check(t, web.Networks == nil)
+	assert.DeepEqual(t, web.DependsOn, types.DependsOnConfig{
+		"cache": {Condition: types.ServiceConditionHealthy, Required: true},
+	})
+	assert.DeepEqual(t, p.Services["db"].Networks, map[string]*types.ServiceNetworkConfig{"back": nil})
+	assert.Check(t, p.Services["cache"].DependsOn == nil)
+
+	marshal, err := p.MarshalYAML()
+	assert.NilError(t, err)
+	reloaded, err := load(string(marshal))
+	assert.NilError(t, err)
+	assert.Equal(t, reloaded.Services["web"].Build.Dockerfile, "Dockerfile")
+	assert.Equal(t, len(reloaded.Services["cache"].DependsOn), 1)
+}
+
+func TestNormalizeDeprecatedDiagnostics(t *testing.T) {
+	project := types.Project{
+		Services: types.Services{
+			"foo": {
+				Name:      "foo",
+				Image:     "foo",
+				LogDriver: "syslog",
+				LogOpt:    map[string]string{"tag": "foo"},
+			},
+		},
+	}
+	collector := &diagnostics.Collector{}
+	err := normalize(&project, collector)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, collector.Diagnostics(), []diagnostics.Diagnostic{
+		{
+			Code:    diagnostics.Deprecated,
+			Message: "`log_driver` is deprecated. Use the `logging` element",
+			Path:    "services.foo.log_driver",
+		},
+		{
+			Code:    diagnostics.Deprecated,
+			Message: "`log_opts` is deprecated. Use the `logging` element",
+			Path:    "services.foo.log_opt",
+		},
+	})
+	assert.Equal(t, project.Services["foo"].Logging.Driver, "syslog")
+}
@@ -17,20 +17,37 @@
 package loader
 
 import (
-	"fmt"
+	"reflect"
 	"strconv"
 
+	"github.com/compose-spec/compose-go/v2/consts"
+	"github.com/compose-spec/compose-go/v2/override"
+	"github.com/compose-spec/compose-go/v2/schema"
+	"github.com/compose-spec/compose-go/v2/transform"
 	"github.com/compose-spec/compose-go/v2/tree"
 	"gopkg.in/yaml.v3"
 )
 
+// ResetProcessor handles the `!reset` and `!override` yaml tags. A node tagged `!reset` is removed from the
+// model and the matching attribute is reset in the model it overrides. A node tagged `!override` also resets the
+// overridden attribute, so that it gets wholesale replaced by the tagged node when models are merged.
 type ResetProcessor struct {
 	target interface{}
 	paths  []tree.Path
+	items  []resetItem
+	// tags are the usages of `!reset` and `!override`, which are not supported by all versions
+	tags []schema.FeatureUsage
+}
+
+// resetItem is a sequence item tagged `!reset`, to be removed from the overridden sequence at path
+type resetItem struct {
+	path  tree.Path
+	value any
 }
 
 // UnmarshalYAML implement yaml.Unmarshaler
 func (p *ResetProcessor) UnmarshalYAML(value *yaml.Node) error {
+	p.tags = schema.UsedTags(value)
 	resolved, err := p.resolveReset(value, tree.NewPath())
 	if err != nil {
 		return err
@@ -38,16 +55,29 @@ func (p *ResetProcessor) UnmarshalYAML(value *yaml.Node) error {
 	return resolved.Decode(p.target)
 }
 
-// resolveReset detects `!reset` tag being set on yaml nodes and record position in the yaml tree
+// resolveReset detects `!reset` and `!override` tags being set on yaml nodes and record position in the yaml tree
 func (p *ResetProcessor) resolveReset(node *yaml.Node, path tree.Path) (*yaml.Node, error) {
-	if node.Tag == "!reset" {
+	switch node.Tag {
+	case "!reset":
 		p.paths = append(p.paths, path)
 		return nil, nil
+	case "!override":
+		p.paths = append(p.paths, path)
+		// let yaml resolve the actual node type
+		node.Tag = ""
 	}
 	switch node.Kind {
 	case yaml.SequenceNode:
 		var nodes []*yaml.Node
 		for idx, v := range node.Content {
+			if v.Tag == "!reset" {
+				item, err := resetItemValue(v)
+				if err != nil {
+					return nil, err
+				}
+				p.items = append(p.items, resetItem{path: path, value: item})
+				continue
+			}
 			next := path.Next(strconv.Itoa(idx))
 			resolved, err := p.resolveReset(v, next)
 			if err != nil {
@@ -79,13 +109,24 @@ func (p *ResetProcessor) resolveReset(node *yaml.Node, path tree.Path) (*yaml.No
 	return node, nil
 }
 
+// resetItemValue decodes a sequence item tagged `!reset`
+func resetItemValue(node *yaml.Node) (any, error) {
+	node.Tag = ""
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	return convertToStringKeysRecursive(value, "")
+}
+
 // Apply finds the go attributes matching recorded paths and reset them to zero value
 func (p *ResetProcessor) Apply(target any) error {
-	return p.applyNullOverrides(target, tree.NewPath())
+	_, err := p.applyNullOverrides(target, tree.NewPath())
+	return err
 }
 
-// applyNullOverrides set val to Zero if it matches any of the recorded paths
-func (p *ResetProcessor) applyNullOverrides(target any, path tree.Path) error {
+// applyNullOverrides set val to Zero if it matches any of the recorded paths, and removes reset items from sequences
+func (p *ResetProcessor) applyNullOverrides(target any, path tree.Path) (any, error) {
 	switch v := target.(type) {
 	case map[string]any:
 	KEYS:
@@ -97,25 +138,131 @@ func (p *ResetProcessor) applyNullOverrides(target any, path tree.Path) error {
 					continue KEYS
 				}
 			}
-			err := p.applyNullOverrides(e, next)
+			if err := p.removeItemsFromMapping(e, next); err != nil {
+				return nil, err
+			}
+			e, err := p.applyNullOverrides(e, next)
 			if err != nil {
-				return err
+				return nil, err
 			}
+			v[k] = e
 		}
 	case []any:
-	ITER:
+		var seq []any
 		for i, e := range v {
-			next := path.Next(fmt.Sprintf("[%d]", i))
-			for _, pattern := range p.paths {
-				if next.Matches(pattern) {
-					continue ITER
-					// TODO(ndeloof) support removal from sequence
-				}
+			removed, err := p.isResetItem(e, path, i)
+			if err != nil {
+				return nil, err
 			}
-			err := p.applyNullOverrides(e, next)
+			if removed {
+				continue
+			}
+			e, err = p.applyNullOverrides(e, path.Next(strconv.Itoa(i)))
 			if err != nil {
-				return err
+				return nil, err
+			}
+			seq = append(seq, e)
+		}
+		if len(seq) < len(v) {
+			return seq, nil
+		}
+	}
+	return target, nil
+}
+
+// isResetItem checks if entry at index i in sequence at path matches a reset item. Items are matched by the
+// identity used to enforce unicity in sequence, or by equality if sequence items have no such identity
+func (p *ResetProcessor) isResetItem(entry any, path tree.Path, i int) (bool, error) {
+	for _, item := range p.items {
+		if !path.Matches(item.path) {
+			continue
+		}
+		key, ok, err := override.Identity(path, entry, i)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			if reflect.DeepEqual(canonicalItem(path, entry), canonicalItem(path, item.value)) {
+				return true, nil
 			}
+			continue
+		}
+		reset, _, err := override.Identity(path, item.value, i)
+		if err != nil {
+			return false, err
+		}
+		if key == reset {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// validateItems checks sequence items tagged `!reset` against the compose specification, as those are removed
+// from the model before it gets validated
+func (p *ResetProcessor) validateItems() error {
+	for _, item := range p.items {
+		if err := schema.Validate(itemModel(item.path, item.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemModel creates a model with item as the single entry in sequence at path
+func itemModel(path tree.Path, item any) map[string]any {
+	var model any = []any{deepClone(item)}
+	parts := path.Parts()
+	for i := len(parts) - 1; i >= 0; i-- {
+		model = map[string]any{parts[i]: model}
+	}
+	return model.(map[string]any)
+}
+
+// canonicalItem converts a sequence item into canonical syntax, so that short and long syntax can be compared
+func canonicalItem(path tree.Path, item any) any {
+	canonical, err := transform.Canonical(itemModel(path, item))
+	if err != nil {
+		return item
+	}
+	var value any = canonical
+	for _, part := range path.Parts() {
+		value = value.(map[string]any)[part]
+	}
+	// canonical syntax may be produced by encoding go types, normalize value types as parsed from yaml
+	b, err := yaml.Marshal(value)
+	if err != nil {
+		return item
+	}
+	var normalized []any
+	if err := yaml.Unmarshal(b, &normalized); err != nil {
+		return item
+	}
+	for _, e := range normalized {
+		if m, ok := e.(map[string]any); ok {
+			delete(m, consts.Extensions)
+		}
+	}
+	return normalized
+}
+
+// removeItemsFromMapping removes entries matching reset items when attribute at path, declared in
+// overridden model using the mapping syntax, is reset using the sequence syntax (typically `environment`)
+func (p *ResetProcessor) removeItemsFromMapping(target any, path tree.Path) error {
+	mapping, ok := target.(map[string]any)
+	if !ok {
+		return nil
+	}
+	for _, item := range p.items {
+		if !path.Matches(item.path) {
+			continue
+		}
+		key, ok, err := override.Identity(path, item.value, 0)
+		if err != nil {
+			return err
+		}
+		if ok {
+			delete(mapping, key)
 		}
 	}
 	return nil
@@ -21,6 +21,9 @@ import (
 	"os"
 	"testing"
 
+	"github.com/compose-spec/compose-go/v2/transform"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 )
@@ -45,6 +48,31 @@ func TestMarshalProject(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestMarshalCompactProject(t *testing.T) {
+	workingDir, err := os.Getwd()
+	assert.NilError(t, err)
+	homeDir, err := os.UserHomeDir()
+	assert.NilError(t, err)
+	project := fullExampleProject(workingDir, homeDir)
+
+	compact, err := project.MarshalYAML(types.WithTransform(transform.Compact))
+	assert.NilError(t, err)
+	verbose, err := project.MarshalYAML()
+	assert.NilError(t, err)
+	assert.Check(t, len(compact) < len(verbose))
+
+	// compact syntax must load as the same model as the verbose one
+	loadOptions := func(options *Options) {
+		options.SkipNormalization = true
+		options.SkipConsistencyCheck = true
+	}
+	expected, err := Load(buildConfigDetails(string(verbose), map[string]string{}), loadOptions)
+	assert.NilError(t, err)
+	actual, err := Load(buildConfigDetails(string(compact), map[string]string{}), loadOptions)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, actual, expected, cmpopts.IgnoreFields(types.Project{}, "InputFiles"))
+}
+
 func TestJSONMarshalProject(t *testing.T) {
 	workingDir, err := os.Getwd()
 	assert.NilError(t, err)
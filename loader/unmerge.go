@@ -0,0 +1,55 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"github.com/compose-spec/compose-go/v2/override"
+	"github.com/compose-spec/compose-go/v2/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Unmerge computes the minimal compose override file which, loaded on top of base project compose
+// file(s), turns it into desired project. Returns nil if projects are equivalent.
+func Unmerge(base, desired *types.Project) ([]byte, error) {
+	b, err := projectModel(base)
+	if err != nil {
+		return nil, err
+	}
+	d, err := projectModel(desired)
+	if err != nil {
+		return nil, err
+	}
+	unmerged, err := override.Unmerge(b, d)
+	if err != nil {
+		return nil, err
+	}
+	if len(unmerged) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(unmerged)
+}
+
+// projectModel converts project into a yaml tree
+func projectModel(project *types.Project) (map[string]any, error) {
+	b, err := project.MarshalYAML()
+	if err != nil {
+		return nil, err
+	}
+	var model map[string]any
+	err = yaml.Unmarshal(b, &model)
+	return model, err
+}
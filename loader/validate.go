@@ -18,10 +18,13 @@ package loader
 
 import (
 	"fmt"
+	"net/netip"
+	"sort"
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/errdefs"
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/utils"
 	"github.com/pkg/errors"
 )
 
@@ -137,5 +140,192 @@ func checkConsistency(project *types.Project) error {
 		}
 	}
 
+	return checkIPAM(project)
+}
+
+// checkResources validates service-level resources agree with deploy.resources, and limits are at least reservations
+func checkResources(s types.ServiceConfig) error {
+	if s.Deploy != nil {
+		if limits := s.Deploy.Resources.Limits; limits != nil {
+			if s.CPUS != 0 && limits.NanoCPUs != 0 && types.NanoCPUsFromFloat(s.CPUS) != limits.NanoCPUs {
+				return errors.Wrapf(errdefs.ErrInvalid, "services.%s: cpus (%v) and deploy.resources.limits.cpus (%s) are inconsistent",
+					s.Name, s.CPUS, limits.NanoCPUs)
+			}
+			if s.MemLimit != 0 && limits.MemoryBytes != 0 && s.MemLimit != limits.MemoryBytes {
+				return errors.Wrapf(errdefs.ErrInvalid, "services.%s: mem_limit (%d) and deploy.resources.limits.memory (%d) are inconsistent",
+					s.Name, s.MemLimit, limits.MemoryBytes)
+			}
+			if s.PidsLimit != 0 && limits.Pids != 0 && s.PidsLimit != limits.Pids {
+				return errors.Wrapf(errdefs.ErrInvalid, "services.%s: pids_limit (%d) and deploy.resources.limits.pids (%d) are inconsistent",
+					s.Name, s.PidsLimit, limits.Pids)
+			}
+		}
+		if reservations := s.Deploy.Resources.Reservations; reservations != nil {
+			if s.MemReservation != 0 && reservations.MemoryBytes != 0 && s.MemReservation != reservations.MemoryBytes {
+				return errors.Wrapf(errdefs.ErrInvalid, "services.%s: mem_reservation (%d) and deploy.resources.reservations.memory (%d) are inconsistent",
+					s.Name, s.MemReservation, reservations.MemoryBytes)
+			}
+		}
+	}
+
+	resources := s.EffectiveResources()
+	if resources.Limits == nil || resources.Reservations == nil {
+		return nil
+	}
+	limits, reservations := resources.Limits, resources.Reservations
+	if limits.NanoCPUs != 0 && limits.NanoCPUs < reservations.NanoCPUs {
+		return errors.Wrapf(errdefs.ErrInvalid, "services.%s: cpus limit (%s) is lower than reservation (%s)",
+			s.Name, limits.NanoCPUs, reservations.NanoCPUs)
+	}
+	if limits.MemoryBytes != 0 && limits.MemoryBytes < reservations.MemoryBytes {
+		return errors.Wrapf(errdefs.ErrInvalid, "services.%s: memory limit (%d) is lower than reservation (%d)",
+			s.Name, limits.MemoryBytes, reservations.MemoryBytes)
+	}
+	return nil
+}
+
+// checkPortConflicts validates host ports published by services can all be allocated, and reports every conflict
+func checkPortConflicts(project *types.Project) error {
+	ports, err := project.PublishedPorts()
+	if err != nil {
+		return errors.Wrap(errdefs.ErrInvalid, err.Error())
+	}
+	var conflicts []string
+	for i, port := range ports {
+		if size := int(port.End-port.Start) + 1; port.NodeReplicas > size {
+			conflicts = append(conflicts, fmt.Sprintf("services.%s.ports[%d]: %d replicas on a node can't all publish %s (%s mode)",
+				port.Service, port.Index, port.NodeReplicas, port, port.Mode))
+		}
+		for _, other := range ports[i+1:] {
+			if port.Overlaps(other) {
+				conflicts = append(conflicts, fmt.Sprintf("services.%s.ports[%d] and services.%s.ports[%d]: %s (%s mode) overlaps %s (%s mode)",
+					port.Service, port.Index, other.Service, other.Index, port, port.Mode, other, other.Mode))
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return errors.Wrapf(errdefs.ErrInvalid, "host port conflicts:\n%s", strings.Join(conflicts, "\n"))
+	}
+	return nil
+}
+
+// checkIPAM validates networks' IPAM configuration and services' static addresses
+func checkIPAM(project *types.Project) error {
+	type subnet struct {
+		path   string
+		prefix netip.Prefix
+	}
+	var subnets []subnet
+	gateways := map[string][]netip.Addr{}
+	pools := map[string][]netip.Prefix{}
+
+	for _, name := range project.NetworkNames() {
+		network := project.Networks[name]
+		if network.External {
+			continue
+		}
+		for i, pool := range network.Ipam.Config {
+			if pool == nil || pool.Subnet == "" {
+				continue
+			}
+			path := fmt.Sprintf("networks.%s.ipam.config[%d]", name, i)
+			prefix, err := netip.ParsePrefix(pool.Subnet)
+			if err != nil {
+				return errors.Wrapf(errdefs.ErrInvalid, "%s.subnet: invalid subnet %q", path, pool.Subnet)
+			}
+			prefix = prefix.Masked()
+			for _, s := range subnets {
+				if s.prefix.Overlaps(prefix) {
+					return errors.Wrapf(errdefs.ErrInvalid, "%s.subnet: %s overlaps %s set by %s", path, prefix, s.prefix, s.path)
+				}
+			}
+			subnets = append(subnets, subnet{path: path, prefix: prefix})
+			pools[name] = append(pools[name], prefix)
+
+			if pool.Gateway != "" {
+				gateway, err := netip.ParseAddr(pool.Gateway)
+				if err != nil || !prefix.Contains(gateway) {
+					return errors.Wrapf(errdefs.ErrInvalid, "%s.gateway: %s is not in subnet %s", path, pool.Gateway, prefix)
+				}
+				gateways[name] = append(gateways[name], gateway)
+			}
+			if pool.IPRange != "" {
+				ipRange, err := netip.ParsePrefix(pool.IPRange)
+				if err != nil || !prefix.Contains(ipRange.Addr()) || ipRange.Bits() < prefix.Bits() {
+					return errors.Wrapf(errdefs.ErrInvalid, "%s.ip_range: %s is not in subnet %s", path, pool.IPRange, prefix)
+				}
+			}
+			hosts := utils.MapKeys(pool.AuxiliaryAddresses)
+			sort.Strings(hosts)
+			for _, host := range hosts {
+				aux := pool.AuxiliaryAddresses[host]
+				addr, err := netip.ParseAddr(aux)
+				if err != nil || !prefix.Contains(addr) {
+					return errors.Wrapf(errdefs.ErrInvalid, "%s.aux_addresses.%s: %s is not in subnet %s", path, host, aux, prefix)
+				}
+			}
+		}
+	}
+
+	assigned := map[string]string{}
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		networks := utils.MapKeys(service.Networks)
+		sort.Strings(networks)
+		for _, network := range networks {
+			config := service.Networks[network]
+			if config == nil {
+				continue
+			}
+			for _, static := range []struct {
+				attr    string
+				address string
+				is6     bool
+			}{
+				{"ipv4_address", config.Ipv4Address, false},
+				{"ipv6_address", config.Ipv6Address, true},
+			} {
+				if static.address == "" {
+					continue
+				}
+				path := fmt.Sprintf("services.%s.networks.%s.%s", name, network, static.attr)
+				addr, err := netip.ParseAddr(static.address)
+				if err != nil || addr.Is6() != static.is6 {
+					return errors.Wrapf(errdefs.ErrInvalid, "%s: invalid address %q", path, static.address)
+				}
+				if service.GetScale() > 1 {
+					return errors.Wrapf(errdefs.ErrInvalid, "%s: static address can't be shared by %d replicas", path, service.GetScale())
+				}
+				key := network + "/" + addr.String()
+				if other, ok := assigned[key]; ok {
+					return errors.Wrapf(errdefs.ErrInvalid, "%s: address %s is already assigned to service %s", path, addr, other)
+				}
+				assigned[key] = name
+
+				if project.Networks[network].External {
+					// IPAM configuration is not known
+					continue
+				}
+				if static.is6 && !project.Networks[network].EnableIPv6 {
+					return errors.Wrapf(errdefs.ErrInvalid, "%s: network %s must set enable_ipv6 to use an IPv6 address", path, network)
+				}
+				inSubnet := false
+				for _, prefix := range pools[network] {
+					if prefix.Contains(addr) {
+						inSubnet = true
+						break
+					}
+				}
+				if !inSubnet {
+					return errors.Wrapf(errdefs.ErrInvalid, "%s: %s is not in a subnet configured for network %s", path, addr, network)
+				}
+				for _, gateway := range gateways[network] {
+					if gateway == addr {
+						return errors.Wrapf(errdefs.ErrInvalid, "%s: %s is the gateway of network %s", path, addr, network)
+					}
+				}
+			}
+		}
+	}
 	return nil
 }
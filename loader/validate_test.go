@@ -17,10 +17,12 @@
 package loader
 
 import (
+	"context"
 	"testing"
 
 	"gotest.tools/v3/assert"
 
+	"github.com/compose-spec/compose-go/v2/errdefs"
 	"github.com/compose-spec/compose-go/v2/types"
 )
 
@@ -259,3 +261,285 @@ func TestValidateDependsOn(t *testing.T) {
 	err := checkConsistency(&project)
 	assert.Error(t, err, `service "myservice" depends on undefined service missingservice: invalid compose project`)
 }
+
+func TestValidatePortConflicts(t *testing.T) {
+	project, err := LoadWithContext(context.TODO(), types.ConfigDetails{
+		ConfigFiles: []types.ConfigFile{{Filename: "compose.yaml", Content: []byte(`
+name: ports
+services:
+  web:
+    image: nginx
+    ports:
+      - 8080:80
+      - target: 9000
+        published: 9000-9001
+        host_ip: 127.0.0.1
+        mode: host
+  api:
+    image: api
+    scale: 2
+    ports:
+      - 8080:8080
+      - 8080:8080/udp
+  dns:
+    image: dns
+    ports:
+      - target: 53
+        published: "9001"
+        host_ip: 10.0.0.1
+        mode: host
+      - 127.0.0.1:5353:53
+  worker:
+    image: worker
+    deploy:
+      replicas: 3
+      placement:
+        max_replicas_per_node: 2
+    ports:
+      - target: 7000
+        published: "7000"
+        mode: host
+      - target: 5353
+        published: "5353"
+        host_ip: 10.0.0.1
+        mode: host
+  admin:
+    image: admin
+    profiles: [debug]
+    ports:
+      - 8080:80
+`)}},
+	}, func(options *Options) {
+		options.CheckPortConflicts = true
+	})
+	assert.Assert(t, project == nil)
+	assert.ErrorIs(t, err, errdefs.ErrInvalid)
+	assert.Error(t, err, `host port conflicts:
+services.dns.ports[1] and services.worker.ports[1]: 127.0.0.1:5353/tcp (ingress mode) overlaps 10.0.0.1:5353/tcp (host mode)
+services.worker.ports[1]: 2 replicas on a node can't all publish 10.0.0.1:5353/tcp (host mode)
+services.worker.ports[0]: 2 replicas on a node can't all publish 0.0.0.0:7000/tcp (host mode)
+services.api.ports[0] and services.web.ports[0]: 0.0.0.0:8080/tcp (ingress mode) overlaps 0.0.0.0:8080/tcp (ingress mode): invalid compose project`)
+}
+
+func TestValidatePortConflictsDisabled(t *testing.T) {
+	_, err := LoadWithContext(context.TODO(), types.ConfigDetails{
+		ConfigFiles: []types.ConfigFile{{Filename: "compose.yaml", Content: []byte(`
+name: ports
+services:
+  web:
+    image: nginx
+    ports:
+      - 8080:80
+  api:
+    image: api
+    ports:
+      - 8080:8080
+`)}},
+	})
+	assert.NilError(t, err)
+}
+
+func TestValidateResourcesDisabled(t *testing.T) {
+	_, err := Load(buildConfigDetails(`
+name: resources
+services:
+  foo:
+    image: alpine
+    mem_limit: 512m
+    mem_reservation: 1g
+`, nil))
+	assert.NilError(t, err)
+}
+
+func TestValidateIPAM(t *testing.T) {
+	networks := func(pools ...*types.IPAMPool) types.Networks {
+		return types.Networks{
+			"front": {Ipam: types.IPAMConfig{Config: pools}},
+			"back": {
+				EnableIPv6: true,
+				Ipam: types.IPAMConfig{Config: []*types.IPAMPool{
+					{Subnet: "10.2.0.0/16", Gateway: "10.2.0.1"},
+					{Subnet: "2001:db8::/64"},
+				}},
+			},
+		}
+	}
+	service := func(name string, network string, config types.ServiceNetworkConfig) types.ServiceConfig {
+		return types.ServiceConfig{
+			Name:     name,
+			Image:    "nginx",
+			Networks: map[string]*types.ServiceNetworkConfig{network: &config},
+		}
+	}
+	tests := []struct {
+		name     string
+		networks types.Networks
+		services []types.ServiceConfig
+		err      string
+	}{
+		{
+			name: "valid",
+			networks: networks(&types.IPAMPool{
+				Subnet:             "10.1.0.0/16",
+				Gateway:            "10.1.0.1",
+				IPRange:            "10.1.5.0/24",
+				AuxiliaryAddresses: types.Mapping{"host1": "10.1.0.5"},
+			}),
+			services: []types.ServiceConfig{
+				service("web", "front", types.ServiceNetworkConfig{Ipv4Address: "10.1.0.10"}),
+				service("api", "back", types.ServiceNetworkConfig{Ipv4Address: "10.2.0.10", Ipv6Address: "2001:db8::10"}),
+			},
+		},
+		{
+			name:     "invalid subnet",
+			networks: networks(&types.IPAMPool{Subnet: "10.1.0.0"}),
+			err:      `networks.front.ipam.config[0].subnet: invalid subnet "10.1.0.0"`,
+		},
+		{
+			name:     "overlapping subnets",
+			networks: networks(&types.IPAMPool{Subnet: "10.0.0.0/8"}),
+			err:      "networks.front.ipam.config[0].subnet: 10.0.0.0/8 overlaps 10.2.0.0/16 set by networks.back.ipam.config[0]",
+		},
+		{
+			name:     "gateway outside subnet",
+			networks: networks(&types.IPAMPool{Subnet: "10.1.0.0/16", Gateway: "10.3.0.1"}),
+			err:      "networks.front.ipam.config[0].gateway: 10.3.0.1 is not in subnet 10.1.0.0/16",
+		},
+		{
+			name:     "ip_range outside subnet",
+			networks: networks(&types.IPAMPool{Subnet: "10.1.0.0/16", IPRange: "10.0.0.0/8"}),
+			err:      "networks.front.ipam.config[0].ip_range: 10.0.0.0/8 is not in subnet 10.1.0.0/16",
+		},
+		{
+			name:     "aux address outside subnet",
+			networks: networks(&types.IPAMPool{Subnet: "10.1.0.0/16", AuxiliaryAddresses: types.Mapping{"host1": "10.3.0.5"}}),
+			err:      "networks.front.ipam.config[0].aux_addresses.host1: 10.3.0.5 is not in subnet 10.1.0.0/16",
+		},
+		{
+			name:     "static address outside subnet",
+			networks: networks(&types.IPAMPool{Subnet: "10.1.0.0/16"}),
+			services: []types.ServiceConfig{service("web", "front", types.ServiceNetworkConfig{Ipv4Address: "10.2.0.10"})},
+			err:      "services.web.networks.front.ipv4_address: 10.2.0.10 is not in a subnet configured for network front",
+		},
+		{
+			name:     "duplicate static address",
+			networks: networks(),
+			services: []types.ServiceConfig{
+				service("api", "back", types.ServiceNetworkConfig{Ipv4Address: "10.2.0.10"}),
+				service("web", "back", types.ServiceNetworkConfig{Ipv4Address: "10.2.0.10"}),
+			},
+			err: "services.web.networks.back.ipv4_address: address 10.2.0.10 is already assigned to service api",
+		},
+		{
+			name:     "static address is gateway",
+			networks: networks(),
+			services: []types.ServiceConfig{service("web", "back", types.ServiceNetworkConfig{Ipv4Address: "10.2.0.1"})},
+			err:      "services.web.networks.back.ipv4_address: 10.2.0.1 is the gateway of network back",
+		},
+		{
+			name:     "ipv6 address without enable_ipv6",
+			networks: networks(&types.IPAMPool{Subnet: "2001:db9::/64"}),
+			services: []types.ServiceConfig{service("web", "front", types.ServiceNetworkConfig{Ipv6Address: "2001:db9::10"})},
+			err:      "services.web.networks.front.ipv6_address: network front must set enable_ipv6 to use an IPv6 address",
+		},
+		{
+			name:     "ipv4 address set as ipv6_address",
+			networks: networks(),
+			services: []types.ServiceConfig{service("web", "back", types.ServiceNetworkConfig{Ipv6Address: "10.2.0.10"})},
+			err:      `services.web.networks.back.ipv6_address: invalid address "10.2.0.10"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &types.Project{Networks: tt.networks, Services: types.Services{}}
+			for _, s := range tt.services {
+				project.Services[s.Name] = s
+			}
+			err := checkConsistency(project)
+			if tt.err == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, errdefs.ErrInvalid)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestValidateResources(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{
+			name: "consistent",
+			yaml: `
+    cpus: 0.5
+    mem_limit: 1g
+    deploy:
+      resources:
+        limits:
+          cpus: "0.5"
+          memory: 1g
+        reservations:
+          cpus: 0.25
+          memory: 512m`,
+		},
+		{
+			name: "cpus mismatch",
+			yaml: `
+    cpus: 1
+    deploy:
+      resources:
+        limits:
+          cpus: "0.5"`,
+			err: "services.foo: cpus (1) and deploy.resources.limits.cpus (0.5) are inconsistent",
+		},
+		{
+			name: "mem_limit mismatch",
+			yaml: `
+    mem_limit: 1g
+    deploy:
+      resources:
+        limits:
+          memory: 512m`,
+			err: "services.foo: mem_limit (1073741824) and deploy.resources.limits.memory (536870912) are inconsistent",
+		},
+		{
+			name: "cpus limit lower than reservation",
+			yaml: `
+    deploy:
+      resources:
+        limits:
+          cpus: 0.5
+        reservations:
+          cpus: 1`,
+			err: "services.foo: cpus limit (0.5) is lower than reservation (1)",
+		},
+		{
+			name: "mem_limit lower than mem_reservation",
+			yaml: `
+    mem_limit: 512m
+    mem_reservation: 1g`,
+			err: "services.foo: memory limit (536870912) is lower than reservation (1073741824)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(buildConfigDetails(`
+name: resources
+services:
+  foo:
+    image: alpine`+tt.yaml, nil), func(options *Options) {
+				options.CheckResources = true
+			})
+			if tt.err == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, errdefs.ErrInvalid)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
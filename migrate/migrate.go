@@ -0,0 +1,359 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package migrate rewrites legacy compose files using current Compose Specification syntax
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/editor"
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/format"
+	"github.com/compose-spec/compose-go/v2/internal/yamlnode"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Change describes a modification applied to a compose file
+type Change struct {
+	// Path is the legacy attribute which has been migrated
+	Path tree.Path
+	// Message describes the change
+	Message string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.Path, c.Message)
+}
+
+type migration struct {
+	root    *yaml.Node
+	changes []Change
+}
+
+// serviceMigration migrates a legacy attribute of a service definition
+type serviceMigration func(m *migration, name string, service *yaml.Node) error
+
+var serviceMigrations = []serviceMigration{
+	migrateLogDriver,
+	migrateLogOpt,
+	migrateDockerfile,
+	migrateNet,
+	migrateVolumeDriver,
+}
+
+// Migrate rewrites legacy attributes of compose file using current syntax, preserving comments, and returns the
+// changes applied:
+// - obsolete `version` is removed
+// - `log_driver` and `log_opt` are moved into `logging`
+// - `dockerfile` is moved into `build`
+// - `net` is renamed `network_mode`
+// - `volume_driver` is set as `driver` on the named volumes used by service, unless other services mounting
+// them don't set the same `volume_driver`
+func Migrate(e *editor.Editor) ([]Change, error) {
+	m := &migration{root: e.Root()}
+	if i := yamlnode.MappingIndex(m.root, "version"); i >= 0 {
+		m.remove(m.root, i)
+		m.report(tree.NewPath("version"), "removed obsolete attribute")
+	}
+	if i := yamlnode.MappingIndex(m.root, "services"); i >= 0 {
+		services := yamlnode.ResolveAlias(m.root.Content[i+1])
+		for j := 0; j+1 < len(services.Content); j += 2 {
+			name := services.Content[j].Value
+			service := yamlnode.ResolveAlias(services.Content[j+1])
+			if service.Kind != yaml.MappingNode {
+				continue
+			}
+			for _, fn := range serviceMigrations {
+				if err := fn(m, name, service); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if len(m.changes) > 0 {
+		e.MarkModified()
+	}
+	return m.changes, nil
+}
+
+func (m *migration) report(path tree.Path, format string, args ...any) {
+	m.changes = append(m.changes, Change{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// remove removes the attribute at index i from mapping node, and returns its key and value nodes
+func (m *migration) remove(node *yaml.Node, i int) (*yaml.Node, *yaml.Node) {
+	key, value := node.Content[i], node.Content[i+1]
+	node.Content = append(node.Content[:i], node.Content[i+2:]...)
+	return key, value
+}
+
+// parent returns the mapping node set for key in service, creating it at index i if missing
+func (m *migration) parent(service *yaml.Node, key string, i int, legacy *yaml.Node) (*yaml.Node, error) {
+	if j := yamlnode.MappingIndex(service, key); j >= 0 {
+		node := yamlnode.Unalias(service, j+1)
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			node.Kind, node.Tag, node.Value = yaml.MappingNode, "!!map", ""
+		}
+		if node.Kind != yaml.MappingNode {
+			return nil, errors.Wrapf(errdefs.ErrInvalid, "%s must be a mapping", key)
+		}
+		return node, nil
+	}
+	k := yamlnode.KeyNode(key)
+	// legacy attribute comment is moved to the new parent attribute
+	k.HeadComment, legacy.HeadComment = legacy.HeadComment, ""
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	service.Content = append(service.Content[:i], append([]*yaml.Node{k, node}, service.Content[i:]...)...)
+	return node, nil
+}
+
+// relocate moves the legacy attribute of service under parent attribute, as child
+func (m *migration) relocate(name string, service *yaml.Node, legacy string, parent string, child string) error {
+	i := yamlnode.MappingIndex(service, legacy)
+	if i < 0 {
+		return nil
+	}
+	path := tree.NewPath("services", name, legacy)
+	key, value := m.remove(service, i)
+	target, err := m.parent(service, parent, i, key)
+	if err != nil {
+		return errors.Wrapf(err, "cannot migrate %s", path)
+	}
+	if j := yamlnode.MappingIndex(target, child); j >= 0 {
+		existing := yamlnode.ResolveAlias(target.Content[j+1])
+		if existing.Kind != yaml.ScalarNode || existing.Value != yamlnode.ResolveAlias(value).Value {
+			return errors.Wrapf(errdefs.ErrInvalid, "can't use both '%s' (deprecated) and '%s.%s'", legacy, parent, child)
+		}
+		m.report(path, "removed, as %s.%s is already set", parent, child)
+		return nil
+	}
+	key.Value = child
+	target.Content = append(target.Content, key, value)
+	m.report(path, "moved to %s.%s", parent, child)
+	return nil
+}
+
+func migrateLogDriver(m *migration, name string, service *yaml.Node) error {
+	return m.relocate(name, service, "log_driver", "logging", "driver")
+}
+
+func migrateLogOpt(m *migration, name string, service *yaml.Node) error {
+	i := yamlnode.MappingIndex(service, "log_opt")
+	if i < 0 {
+		return nil
+	}
+	var logging *yaml.Node
+	if j := yamlnode.MappingIndex(service, "logging"); j >= 0 {
+		logging = yamlnode.ResolveAlias(service.Content[j+1])
+	}
+	if logging == nil || yamlnode.MappingIndex(logging, "options") < 0 {
+		return m.relocate(name, service, "log_opt", "logging", "options")
+	}
+
+	path := tree.NewPath("services", name, "log_opt")
+	opts := yamlnode.ResolveAlias(service.Content[i+1])
+	if opts.Kind != yaml.MappingNode {
+		return errors.Wrapf(errdefs.ErrInvalid, "%s must be a mapping", path)
+	}
+	options := yamlnode.Unalias(logging, yamlnode.MappingIndex(logging, "options")+1)
+	if options.Kind != yaml.MappingNode {
+		return errors.Wrapf(errdefs.ErrInvalid, "%s must be a mapping", tree.NewPath("services", name, "logging", "options"))
+	}
+	for j := 0; j+1 < len(opts.Content); j += 2 {
+		k := yamlnode.MappingIndex(options, opts.Content[j].Value)
+		if k < 0 {
+			options.Content = append(options.Content, opts.Content[j], opts.Content[j+1])
+			continue
+		}
+		if yamlnode.ResolveAlias(options.Content[k+1]).Value != yamlnode.ResolveAlias(opts.Content[j+1]).Value {
+			return errors.Wrap(errdefs.ErrInvalid, "can't use both 'log_opt' (deprecated) and 'logging.options'")
+		}
+	}
+	m.remove(service, i)
+	m.report(path, "merged into logging.options")
+	return nil
+}
+
+func migrateDockerfile(m *migration, name string, service *yaml.Node) error {
+	i := yamlnode.MappingIndex(service, "dockerfile")
+	if i < 0 {
+		return nil
+	}
+	if j := yamlnode.MappingIndex(service, "build"); j >= 0 {
+		if build := yamlnode.ResolveAlias(service.Content[j+1]); build.Kind == yaml.ScalarNode && build.Tag != "!!null" {
+			// short syntax: build is the context path
+			context := &yaml.Node{Kind: yaml.ScalarNode, Tag: build.Tag, Value: build.Value, Style: build.Style}
+			service.Content[j+1] = &yaml.Node{
+				Kind:    yaml.MappingNode,
+				Tag:     "!!map",
+				Content: []*yaml.Node{yamlnode.KeyNode("context"), context},
+			}
+			context.LineComment = build.LineComment
+			m.report(tree.NewPath("services", name, "build"), "converted to long syntax")
+		}
+	}
+	return m.relocate(name, service, "dockerfile", "build", "dockerfile")
+}
+
+func migrateNet(m *migration, name string, service *yaml.Node) error {
+	i := yamlnode.MappingIndex(service, "net")
+	if i < 0 {
+		return nil
+	}
+	path := tree.NewPath("services", name, "net")
+	if j := yamlnode.MappingIndex(service, "network_mode"); j >= 0 {
+		if yamlnode.ResolveAlias(service.Content[j+1]).Value != yamlnode.ResolveAlias(service.Content[i+1]).Value {
+			return errors.Wrap(errdefs.ErrInvalid, "can't use both 'net' (deprecated) and 'network_mode'")
+		}
+		m.remove(service, i)
+		m.report(path, "removed, as network_mode is already set")
+		return nil
+	}
+	service.Content[i].Value = "network_mode"
+	m.report(path, "renamed network_mode")
+	return nil
+}
+
+func migrateVolumeDriver(m *migration, name string, service *yaml.Node) error {
+	i := yamlnode.MappingIndex(service, "volume_driver")
+	if i < 0 {
+		return nil
+	}
+	path := tree.NewPath("services", name, "volume_driver")
+	driver := yamlnode.ResolveAlias(service.Content[i+1])
+
+	var named, anonymous []string
+	if volumes := yamlnode.MappingValue(service, "volumes"); volumes != nil && yamlnode.ResolveAlias(volumes).Kind == yaml.SequenceNode {
+		for _, item := range yamlnode.ResolveAlias(volumes).Content {
+			volume, err := serviceVolume(yamlnode.ResolveAlias(item))
+			if err != nil {
+				return errors.Wrapf(err, "cannot migrate %s", path)
+			}
+			if volume.Type != types.VolumeTypeVolume {
+				continue
+			}
+			if volume.Source == "" {
+				anonymous = append(anonymous, volume.Target)
+			} else {
+				named = append(named, volume.Source)
+			}
+		}
+	}
+
+	for _, volume := range named {
+		if err := m.setVolumeDriver(name, volume, driver, path); err != nil {
+			return err
+		}
+	}
+	if len(anonymous) > 0 {
+		m.report(path, "kept, as it applies to anonymous volume(s) %s", strings.Join(anonymous, ", "))
+		return nil
+	}
+	m.remove(service, i)
+	if len(named) == 0 {
+		m.report(path, "removed, as service doesn't use any volume")
+	}
+	return nil
+}
+
+// setVolumeDriver sets driver on top-level volume definition, creating it if missing. As this also applies to other
+// services mounting the volume, they must all set the same volume_driver.
+func (m *migration) setVolumeDriver(service string, name string, driver *yaml.Node, path tree.Path) error {
+	volumes, err := m.parent(m.root, "volumes", len(m.root.Content), &yaml.Node{})
+	if err != nil {
+		return errors.Wrapf(err, "cannot migrate %s", path)
+	}
+	i := yamlnode.MappingIndex(volumes, name)
+	if i < 0 {
+		volumes.Content = append(volumes.Content, yamlnode.KeyNode(name), &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+		i = len(volumes.Content) - 2
+	}
+	volume := yamlnode.Unalias(volumes, i+1)
+	if volume.Kind == yaml.ScalarNode && volume.Tag == "!!null" {
+		volume.Kind, volume.Tag, volume.Value = yaml.MappingNode, "!!map", ""
+	}
+	if volume.Kind != yaml.MappingNode {
+		return errors.Wrapf(errdefs.ErrInvalid, "volumes.%s must be a mapping", name)
+	}
+	if external := yamlnode.MappingValue(volume, "external"); external != nil && yamlnode.ResolveAlias(external).Value != "false" {
+		m.report(path, "ignored for external volume %s", name)
+		return nil
+	}
+	if j := yamlnode.MappingIndex(volume, "driver"); j >= 0 {
+		if yamlnode.ResolveAlias(volume.Content[j+1]).Value != driver.Value {
+			return errors.Wrapf(errdefs.ErrInvalid, "can't use both 'volume_driver' (deprecated) and 'volumes.%s.driver'", name)
+		}
+		m.report(path, "removed, as volumes.%s.driver is already set", name)
+		return nil
+	}
+	if others := m.volumeUsers(name, service, driver.Value); len(others) > 0 {
+		return errors.Wrapf(errdefs.ErrInvalid, "cannot migrate %s: volume %s is also used by service(s) %s, which would then use driver %s",
+			path, name, strings.Join(others, ", "), driver.Value)
+	}
+	volume.Content = append(volume.Content, yamlnode.KeyNode("driver"), &yaml.Node{Kind: yaml.ScalarNode, Tag: driver.Tag, Value: driver.Value})
+	m.report(path, "moved to volumes.%s.driver", name)
+	return nil
+}
+
+// volumeUsers returns the services, other than service, which mount named volume without setting volume_driver
+// to driver
+func (m *migration) volumeUsers(name string, service string, driver string) []string {
+	services := yamlnode.MappingValue(m.root, "services")
+	if services == nil {
+		return nil
+	}
+	services = yamlnode.ResolveAlias(services)
+	var users []string
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		other := yamlnode.ResolveAlias(services.Content[i+1])
+		if services.Content[i].Value == service || other.Kind != yaml.MappingNode {
+			continue
+		}
+		if d := yamlnode.MappingValue(other, "volume_driver"); d != nil && yamlnode.ResolveAlias(d).Value == driver {
+			continue
+		}
+		volumes := yamlnode.MappingValue(other, "volumes")
+		if volumes == nil || yamlnode.ResolveAlias(volumes).Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range yamlnode.ResolveAlias(volumes).Content {
+			volume, err := serviceVolume(yamlnode.ResolveAlias(item))
+			if err == nil && volume.Type == types.VolumeTypeVolume && volume.Source == name {
+				users = append(users, services.Content[i].Value)
+				break
+			}
+		}
+	}
+	return users
+}
+
+// serviceVolume decodes a service volume declared using short or long syntax
+func serviceVolume(node *yaml.Node) (types.ServiceVolumeConfig, error) {
+	if node.Kind == yaml.ScalarNode {
+		return format.ParseVolume(node.Value)
+	}
+	var volume types.ServiceVolumeConfig
+	err := node.Decode(&volume)
+	return volume, err
+}
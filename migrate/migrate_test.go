@@ -0,0 +1,213 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package migrate
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/editor"
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"gotest.tools/v3/assert"
+)
+
+func migrate(t *testing.T, content string) (string, []string) {
+	t.Helper()
+	e, err := editor.Parse([]byte(content))
+	assert.NilError(t, err)
+	changes, err := Migrate(e)
+	assert.NilError(t, err)
+	b, err := e.Bytes()
+	assert.NilError(t, err)
+	var report []string
+	for _, c := range changes {
+		report = append(report, c.String())
+	}
+	return string(b), report
+}
+
+func TestMigrate(t *testing.T) {
+	migrated, report := migrate(t, `version: "2.4"
+services:
+  web:
+    build: ./web # sources
+    dockerfile: Dockerfile.dev
+    # legacy logging
+    log_driver: syslog
+    log_opt:
+      syslog-address: "udp://127.0.0.1:514"
+    net: host
+  db:
+    image: postgres
+    volume_driver: flocker
+    volumes:
+      - data:/var/lib/postgresql/data
+      - type: volume
+        source: backup
+        target: /backup
+volumes:
+  data:
+`)
+	assert.Equal(t, migrated, `services:
+  web:
+    build:
+      context: ./web # sources
+      dockerfile: Dockerfile.dev
+    # legacy logging
+    logging:
+      driver: syslog
+      options:
+        syslog-address: "udp://127.0.0.1:514"
+    network_mode: host
+  db:
+    image: postgres
+    volumes:
+      - data:/var/lib/postgresql/data
+      - type: volume
+        source: backup
+        target: /backup
+volumes:
+  data:
+    driver: flocker
+  backup:
+    driver: flocker
+`)
+	assert.DeepEqual(t, report, []string{
+		"version: removed obsolete attribute",
+		"services.web.log_driver: moved to logging.driver",
+		"services.web.log_opt: moved to logging.options",
+		"services.web.build: converted to long syntax",
+		"services.web.dockerfile: moved to build.dockerfile",
+		"services.web.net: renamed network_mode",
+		"services.db.volume_driver: moved to volumes.data.driver",
+		"services.db.volume_driver: moved to volumes.backup.driver",
+	})
+}
+
+func TestMigrateMergeLogOpt(t *testing.T) {
+	migrated, report := migrate(t, `services:
+  web:
+    image: nginx
+    log_opt:
+      max-size: 10m
+    logging:
+      options:
+        max-file: "3"
+`)
+	assert.Equal(t, migrated, `services:
+  web:
+    image: nginx
+    logging:
+      options:
+        max-file: "3"
+        max-size: 10m
+`)
+	assert.DeepEqual(t, report, []string{"services.web.log_opt: merged into logging.options"})
+}
+
+func TestMigrateAnonymousVolume(t *testing.T) {
+	migrated, report := migrate(t, `services:
+  db:
+    image: postgres
+    volume_driver: flocker
+    volumes:
+      - /data
+`)
+	assert.Equal(t, migrated, `services:
+  db:
+    image: postgres
+    volume_driver: flocker
+    volumes:
+      - /data
+`)
+	assert.DeepEqual(t, report, []string{"services.db.volume_driver: kept, as it applies to anonymous volume(s) /data"})
+}
+
+func TestMigrateConflict(t *testing.T) {
+	e, err := editor.Parse([]byte(`services:
+  web:
+    image: nginx
+    log_driver: syslog
+    logging:
+      driver: json-file
+`))
+	assert.NilError(t, err)
+	_, err = Migrate(e)
+	assert.ErrorIs(t, err, errdefs.ErrInvalid)
+	assert.ErrorContains(t, err, "can't use both 'log_driver' (deprecated) and 'logging.driver'")
+}
+
+func TestMigrateSharedVolume(t *testing.T) {
+	e, err := editor.Parse([]byte(`services:
+  db:
+    image: postgres
+    volume_driver: flocker
+    volumes:
+      - data:/var/lib/postgresql/data
+  backup:
+    image: backup
+    volumes:
+      - data:/backup
+`))
+	assert.NilError(t, err)
+	_, err = Migrate(e)
+	assert.ErrorIs(t, err, errdefs.ErrInvalid)
+	assert.ErrorContains(t, err, "cannot migrate services.db.volume_driver: volume data is also used by service(s) backup")
+
+	// services setting the same volume_driver can share the volume
+	migrated, report := migrate(t, `services:
+  db:
+    image: postgres
+    volume_driver: flocker
+    volumes:
+      - data:/var/lib/postgresql/data
+  backup:
+    image: backup
+    volume_driver: flocker
+    volumes:
+      - data:/backup
+`)
+	assert.Equal(t, migrated, `services:
+  db:
+    image: postgres
+    volumes:
+      - data:/var/lib/postgresql/data
+  backup:
+    image: backup
+    volumes:
+      - data:/backup
+volumes:
+  data:
+    driver: flocker
+`)
+	assert.DeepEqual(t, report, []string{
+		"services.db.volume_driver: moved to volumes.data.driver",
+		"services.backup.volume_driver: removed, as volumes.data.driver is already set",
+	})
+}
+
+func TestMigrateUpToDate(t *testing.T) {
+	content := `services:
+  web:
+    image: nginx
+`
+	e, err := editor.Parse([]byte(content))
+	assert.NilError(t, err)
+	changes, err := Migrate(e)
+	assert.NilError(t, err)
+	assert.Equal(t, len(changes), 0)
+	assert.Check(t, !e.Modified())
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/compose-spec/compose-go/v2/internal/pathrules"
 	"github.com/compose-spec/compose-go/v2/tree"
 	"golang.org/x/exp/slices"
 )
@@ -33,30 +34,46 @@ func Merge(right, left map[string]any) (map[string]any, error) {
 	return merged.(map[string]any), nil
 }
 
-type merger func(any, any, tree.Path) (any, error)
+// MergeFunc merges override into base yaml subtree at path p, and returns the merged subtree
+type MergeFunc func(base any, override any, p tree.Path) (any, error)
 
 // mergeSpecials defines the custom rules applied by compose when merging yaml trees
-var mergeSpecials = map[tree.Path]merger{}
+var mergeSpecials pathrules.Rules[MergeFunc]
+
+// RegisterMergeRule sets a custom merge rule for yaml subtrees matching pattern. This allows to declare
+// merge behavior for extension attributes, which otherwise get replaced by overrides. When patterns overlap,
+// the rule registered with the most specific pattern applies.
+// RegisterMergeRule is designed to be called during program initialization, and is not safe for concurrent use.
+func RegisterMergeRule(pattern tree.Path, fn MergeFunc) {
+	mergeSpecials.Register(pattern, fn)
+}
+
+// hasCustomRule returns true if a merge rule or an indexer has been registered for path p
+func hasCustomRule(p tree.Path) bool {
+	if _, ok := mergeSpecials.Match(p); ok {
+		return true
+	}
+	_, ok := unique.Match(p)
+	return ok
+}
 
 func init() {
-	mergeSpecials["services.*.logging"] = mergeLogging
-	mergeSpecials["services.*.command"] = override
-	mergeSpecials["services.*.entrypoint"] = override
-	mergeSpecials["services.*.healthcheck.test"] = override
-	mergeSpecials["services.*.environment"] = mergeEnvironment
-	mergeSpecials["services.*.ulimits.*"] = mergeUlimit
+	mergeSpecials.Register("services.*.logging", mergeLogging)
+	mergeSpecials.Register("services.*.command", override)
+	mergeSpecials.Register("services.*.entrypoint", override)
+	mergeSpecials.Register("services.*.healthcheck.test", override)
+	mergeSpecials.Register("services.*.environment", mergeEnvironment)
+	mergeSpecials.Register("services.*.ulimits.*", mergeUlimit)
 }
 
 // mergeYaml merges map[string]any yaml trees handling special rules
 func mergeYaml(e any, o any, p tree.Path) (any, error) {
-	for pattern, merger := range mergeSpecials {
-		if p.Matches(pattern) {
-			merged, err := merger(e, o, p)
-			if err != nil {
-				return nil, err
-			}
-			return merged, nil
+	if merger, ok := mergeSpecials.Match(p); ok {
+		merged, err := merger(e, o, p)
+		if err != nil {
+			return nil, err
 		}
+		return merged, nil
 	}
 	switch value := e.(type) {
 	case map[string]any:
@@ -79,11 +96,11 @@ func mergeYaml(e any, o any, p tree.Path) (any, error) {
 func mergeMappings(mapping map[string]any, other map[string]any, p tree.Path) (map[string]any, error) {
 	for k, v := range other {
 		e, ok := mapping[k]
-		if !ok || strings.HasPrefix(k, "x-") {
+		next := p.Next(k)
+		if !ok || (strings.HasPrefix(k, "x-") && !hasCustomRule(next)) {
 			mapping[k] = v
 			continue
 		}
-		next := p.Next(k)
 		merged, err := mergeYaml(e, v, next)
 		if err != nil {
 			return nil, err
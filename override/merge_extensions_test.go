@@ -0,0 +1,158 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package override
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+)
+
+func Test_mergeExtensionDefault(t *testing.T) {
+	assertMergeYaml(t, `
+services:
+  test:
+    x-sidecars:
+      - name: proxy
+        image: envoy
+`, `
+services:
+  test:
+    x-sidecars:
+      - name: logger
+        image: fluentd
+`, `
+services:
+  test:
+    x-sidecars:
+      - name: logger
+        image: fluentd
+`)
+}
+
+func Test_mergeExtensionWithIndexer(t *testing.T) {
+	registered := unique.Clone()
+	t.Cleanup(func() {
+		unique = registered
+	})
+	RegisterIndexer("services.*.x-sidecars", func(item any, _ tree.Path) (string, error) {
+		return item.(map[string]any)["name"].(string), nil
+	})
+
+	assertMergeYaml(t, `
+services:
+  test:
+    x-sidecars:
+      - name: proxy
+        image: envoy
+      - name: logger
+        image: fluentd
+`, `
+services:
+  test:
+    x-sidecars:
+      - name: proxy
+        image: nginx
+      - name: metrics
+        image: prometheus
+`, `
+services:
+  test:
+    x-sidecars:
+      - name: proxy
+        image: nginx
+      - name: logger
+        image: fluentd
+      - name: metrics
+        image: prometheus
+`)
+}
+
+func Test_mergeExtensionWithMergeRule(t *testing.T) {
+	registered := mergeSpecials.Clone()
+	t.Cleanup(func() {
+		mergeSpecials = registered
+	})
+	RegisterMergeRule("services.*.x-tags", func(base any, override any, _ tree.Path) (any, error) {
+		seen := map[any]bool{}
+		var merged []any
+		for _, v := range append(base.([]any), override.([]any)...) {
+			if !seen[v] {
+				seen[v] = true
+				merged = append(merged, v)
+			}
+		}
+		return merged, nil
+	})
+
+	assertMergeYaml(t, `
+services:
+  test:
+    x-tags: [a, b]
+`, `
+services:
+  test:
+    x-tags: [b, c]
+`, `
+services:
+  test:
+    x-tags: [a, b, c]
+`)
+}
+
+func Test_mergeRuleMostSpecificPattern(t *testing.T) {
+	registered := mergeSpecials.Clone()
+	t.Cleanup(func() {
+		mergeSpecials = registered
+	})
+	// most specific rule is registered first, so that it doesn't win by registration order
+	RegisterMergeRule("services.special.command", func(base any, override any, _ tree.Path) (any, error) {
+		return "special", nil
+	})
+	RegisterMergeRule("*.*.command", func(base any, override any, _ tree.Path) (any, error) {
+		return "generic", nil
+	})
+
+	assertMergeYaml(t, `
+services:
+  test:
+    command: [a]
+  special:
+    command: [a]
+jobs:
+  test:
+    command: [a]
+`, `
+services:
+  test:
+    command: [b]
+  special:
+    command: [b]
+jobs:
+  test:
+    command: [b]
+`, `
+services:
+  test:
+    command: [b]
+  special:
+    command: special
+jobs:
+  test:
+    command: generic
+`)
+}
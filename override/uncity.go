@@ -22,20 +22,37 @@ import (
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/format"
+	"github.com/compose-spec/compose-go/v2/internal/pathrules"
 	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
 )
 
-type indexer func(any, tree.Path) (string, error)
+// IndexerFunc computes the key identifying item at path p in a sequence, so that redefinitions can be detected
+type IndexerFunc func(item any, p tree.Path) (string, error)
 
-// mergeSpecials defines the custom rules applied by compose when merging yaml trees
-var unique = map[tree.Path]indexer{}
+// unique defines the sequences which require unicity, and how to identify items
+var unique pathrules.Rules[IndexerFunc]
+
+// identities defines how to identify items in sequences which allow duplicates, so that a `!reset` item
+// matches the declared items regardless of the syntax used
+var identities pathrules.Rules[IndexerFunc]
+
+// RegisterIndexer declares that items in sequences matching pattern must be unique, as identified by fn.
+// When models are merged, a sequence item then overrides the one with the same key, and `!reset` can remove
+// a single item. When patterns overlap, the indexer registered with the most specific pattern applies.
+// RegisterIndexer is designed to be called during program initialization, and is not safe for concurrent use.
+func RegisterIndexer(pattern tree.Path, fn IndexerFunc) {
+	unique.Register(pattern, fn)
+}
 
 func init() {
-	unique["services.*.environment"] = environmentIndexer
-	unique["services.*.volumes"] = volumeIndexer
-	unique["services.*.expose"] = exposeIndexer
-	unique["services.*.secrets"] = mountIndexer("/run/secrets")
-	unique["services.*.configs"] = mountIndexer("")
+	unique.Register("services.*.environment", environmentIndexer)
+	unique.Register("services.*.volumes", volumeIndexer)
+	unique.Register("services.*.expose", exposeIndexer)
+	unique.Register("services.*.secrets", mountIndexer("/run/secrets"))
+	unique.Register("services.*.configs", mountIndexer(""))
+
+	identities.Register("services.*.ports", portIndexer)
 }
 
 // EnforceUnicity removes redefinition of elements declared in a sequence
@@ -59,31 +76,51 @@ func enforceUnicity(value any, p tree.Path) (any, error) {
 		}
 		return v, nil
 	case []any:
-		for pattern, indexer := range unique {
-			if p.Matches(pattern) {
-				var seq []any
-				keys := map[string]int{}
-				for i, entry := range v {
-					key, err := indexer(entry, p.Next(fmt.Sprintf("[%d]", i)))
-					if err != nil {
-						return nil, err
-					}
-					if j, ok := keys[key]; ok {
-						seq[j] = entry
-					} else {
-						seq = append(seq, entry)
-						keys[key] = len(seq) - 1
-					}
+		if indexer, ok := unique.Match(p); ok {
+			var seq []any
+			keys := map[string]int{}
+			for i, entry := range v {
+				key, err := indexer(entry, p.Next(fmt.Sprintf("[%d]", i)))
+				if err != nil {
+					return nil, err
+				}
+				if j, ok := keys[key]; ok {
+					seq[j] = entry
+				} else {
+					seq = append(seq, entry)
+					keys[key] = len(seq) - 1
 				}
-				return seq, nil
 			}
+			return seq, nil
 		}
 	}
 	return value, nil
 }
 
-func environmentIndexer(y any, _ tree.Path) (string, error) {
-	value := y.(string)
+// Identity returns the key identifying item at index i in the sequence at path p, as used to enforce unicity
+// or to match `!reset` items. ok is false when items in this sequence have no such identity
+func Identity(p tree.Path, item any, i int) (key string, ok bool, err error) {
+	indexer, ok := identifier(p)
+	if !ok {
+		return "", false, nil
+	}
+	key, err = indexer(item, p.Next(fmt.Sprintf("[%d]", i)))
+	return key, err == nil, err
+}
+
+// identifier returns the indexer identifying items in the sequence at path p
+func identifier(p tree.Path) (IndexerFunc, bool) {
+	if indexer, ok := unique.Match(p); ok {
+		return indexer, true
+	}
+	return identities.Match(p)
+}
+
+func environmentIndexer(y any, p tree.Path) (string, error) {
+	value, ok := y.(string)
+	if !ok {
+		return "", fmt.Errorf("%s: unsupported environment value %v", p, y)
+	}
 	key, _, found := strings.Cut(value, "=")
 	if !found {
 		return value, nil
@@ -105,8 +142,9 @@ func volumeIndexer(y any, p tree.Path) (string, error) {
 			return "", err
 		}
 		return volume.Target, nil
+	default:
+		return "", fmt.Errorf("%s: unsupported volume value %v", p, y)
 	}
-	return "", nil
 }
 
 func exposeIndexer(a any, path tree.Path) (string, error) {
@@ -120,19 +158,56 @@ func exposeIndexer(a any, path tree.Path) (string, error) {
 	}
 }
 
-func mountIndexer(defaultPath string) indexer {
+// portIndexer identifies a port by host IP, published port, target and protocol, so that short and long syntax
+// declaring the same port binding get the same key
+func portIndexer(y any, p tree.Path) (string, error) {
+	switch value := y.(type) {
+	case int, string:
+		ports, err := types.ParsePortConfig(fmt.Sprint(value))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", p, err)
+		}
+		keys := make([]string, len(ports))
+		for i, port := range ports {
+			keys[i] = portKey(port.HostIP, port.Published, strconv.Itoa(int(port.Target)), port.Protocol)
+		}
+		return strings.Join(keys, ","), nil
+	case map[string]any:
+		if _, ok := value["target"]; !ok {
+			return "", fmt.Errorf("service port %s is missing a target", p)
+		}
+		attr := func(key, defaultValue string) string {
+			if v, ok := value[key]; ok && v != nil {
+				return fmt.Sprint(v)
+			}
+			return defaultValue
+		}
+		return portKey(attr("host_ip", ""), attr("published", ""), attr("target", ""), attr("protocol", "tcp")), nil
+	default:
+		return "", fmt.Errorf("%s: unsupported port value %v", p, y)
+	}
+}
+
+func portKey(hostIP, published, target, protocol string) string {
+	return fmt.Sprintf("%s:%s:%s/%s", hostIP, published, target, strings.ToLower(protocol))
+}
+
+func mountIndexer(defaultPath string) IndexerFunc {
 	return func(a any, path tree.Path) (string, error) {
 		switch v := a.(type) {
 		case string:
 			return fmt.Sprintf("%s/%s", defaultPath, v), nil
 		case map[string]any:
-			t, ok := v["target"]
-			if ok {
-				return t.(string), nil
+			if t, ok := v["target"]; ok {
+				target, ok := t.(string)
+				if !ok {
+					return "", fmt.Errorf("%s: unsupported target value %v", path, t)
+				}
+				return target, nil
 			}
 			return fmt.Sprintf("%s/%s", defaultPath, v["source"]), nil
 		default:
-			return "", fmt.Errorf("%s: unsupported expose value %s", path, a)
+			return "", fmt.Errorf("%s: unsupported mount value %v", path, a)
 		}
 	}
 }
@@ -66,6 +66,62 @@ services:
 `)
 }
 
+func Test_PortsNotUnique(t *testing.T) {
+	assertUnicity(t, `
+services:
+  test:
+    image: foo
+    ports:
+      - "80"
+      - "80"
+      - 8080:80
+      - target: 80
+        published: "8080"
+`, `
+services:
+  test:
+    image: foo
+    ports:
+      - "80"
+      - "80"
+      - 8080:80
+      - target: 80
+        published: "8080"
+`)
+}
+
+func Test_PortIdentity(t *testing.T) {
+	short, ok, err := Identity("services.test.ports", "127.0.0.1:8080:80/udp", 0)
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+	long, _, err := Identity("services.test.ports", map[string]any{
+		"target": 80, "published": "8080", "host_ip": "127.0.0.1", "protocol": "udp",
+	}, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, short, long)
+}
+
+func Test_UnicityUnsupportedValue(t *testing.T) {
+	_, err := EnforceUnicity(unmarshall(t, `
+services:
+  test:
+    image: foo
+    environment:
+      - FOO: bar
+`))
+	assert.ErrorContains(t, err, "services.test.environment.[0]: unsupported environment value map[FOO:bar]")
+
+	_, err = EnforceUnicity(unmarshall(t, `
+services:
+  test:
+    image: foo
+    secrets:
+      - source: foo
+        target: 42
+`))
+	assert.ErrorContains(t, err, "services.test.secrets.[0]: unsupported target value 42")
+}
+
 func assertUnicity(t *testing.T, before string, expected string) {
 	got, err := EnforceUnicity(unmarshall(t, before))
 	assert.NilError(t, err)
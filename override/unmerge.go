@@ -0,0 +1,333 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package override
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/internal/pathrules"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"gopkg.in/yaml.v3"
+)
+
+// Reset is marshalled as a `!reset` yaml node, to remove an attribute, or a sequence item, from the overridden model
+type Reset struct {
+	// Value is the sequence item to be removed, nil when an attribute is reset
+	Value any
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (r Reset) MarshalYAML() (any, error) {
+	return taggedNode("!reset", r.Value)
+}
+
+// Override is marshalled as an `!override` yaml node, to replace an attribute wholesale in the overridden model
+type Override struct {
+	Value any
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (o Override) MarshalYAML() (any, error) {
+	return taggedNode("!override", o.Value)
+}
+
+func taggedNode(tag string, value any) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	node.Tag = tag
+	return node, nil
+}
+
+type unmerger func(base any, desired any, p tree.Path) (any, error)
+
+// unmergeSpecials defines how to compute an override for attributes with a custom merge rule.
+// Attributes with a merge rule not registered here are set with `!override`
+var unmergeSpecials pathrules.Rules[unmerger]
+
+func init() {
+	unmergeSpecials.Register("services.*.logging", unmergeLogging)
+	unmergeSpecials.Register("services.*.command", replace)
+	unmergeSpecials.Register("services.*.entrypoint", replace)
+	unmergeSpecials.Register("services.*.healthcheck.test", replace)
+	unmergeSpecials.Register("services.*.environment", unmergeEnvironment)
+	unmergeSpecials.Register("services.*.ulimits.*", replace)
+}
+
+// Unmerge computes the minimal override to turn base into desired yaml tree by Merge then EnforceUnicity.
+// Attributes and sequence items to be removed are set as Reset, attributes which can't be expressed by
+// merge rules are set as Override, so that the result can be marshalled as a compose override file.
+func Unmerge(base, desired map[string]any) (map[string]any, error) {
+	unmerged, err := unmergeMappings(base, desired, tree.NewPath())
+	if err != nil {
+		return nil, err
+	}
+	return unmerged, nil
+}
+
+// unmergeYaml computes the override for attribute at path p, ok is false when base and desired are equal
+func unmergeYaml(base any, desired any, p tree.Path) (any, bool, error) {
+	if reflect.DeepEqual(base, desired) {
+		return nil, false, nil
+	}
+	if unmerger, ok := unmergeSpecials.Match(p); ok {
+		unmerged, err := unmerger(base, desired, p)
+		return unmerged, true, err
+	}
+	if _, ok := mergeSpecials.Match(p); ok {
+		return Override{Value: desired}, true, nil
+	}
+	switch b := base.(type) {
+	case map[string]any:
+		d, ok := desired.(map[string]any)
+		if !ok {
+			return Override{Value: desired}, true, nil
+		}
+		unmerged, err := unmergeMappings(b, d, p)
+		return unmerged, true, err
+	case []any:
+		d, ok := desired.([]any)
+		if !ok {
+			return Override{Value: desired}, true, nil
+		}
+		unmerged, err := unmergeSequences(b, d, p)
+		return unmerged, true, err
+	default:
+		switch desired.(type) {
+		case map[string]any, []any:
+			return Override{Value: desired}, true, nil
+		}
+		return desired, true, nil
+	}
+}
+
+func unmergeMappings(base, desired map[string]any, p tree.Path) (map[string]any, error) {
+	unmerged := map[string]any{}
+	for k, d := range desired {
+		b, ok := base[k]
+		next := p.Next(k)
+		if !ok || (strings.HasPrefix(k, "x-") && !hasCustomRule(next)) {
+			if !reflect.DeepEqual(b, d) {
+				unmerged[k] = d
+			}
+			continue
+		}
+		v, changed, err := unmergeYaml(b, d, next)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			unmerged[k] = v
+		}
+	}
+	for k := range base {
+		if _, ok := desired[k]; !ok {
+			unmerged[k] = Reset{}
+		}
+	}
+	return unmerged, nil
+}
+
+// unmergeSequences computes the items to append to base, and the ones to reset, so that merged sequence
+// matches desired. If this can't be achieved, desired is set as Override
+func unmergeSequences(base, desired []any, p tree.Path) (any, error) {
+	indexer, _ := unique.Match(p)
+	var unmerged []any
+	if indexer == nil {
+		unmerged = unmergeAppend(base, desired)
+	} else {
+		var err error
+		unmerged, err = unmergeIndexed(base, desired, p, indexer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resetIndexer, _ := identifier(p)
+	merged, err := simulateMerge(base, unmerged, p, indexer, resetIndexer)
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(merged, desired) {
+		return Override{Value: desired}, nil
+	}
+	return unmerged, nil
+}
+
+// unmergeAppend computes resets and appended items for a sequence without unicity, where items are
+// reset by equality. Base items kept in desired must appear in the same order
+func unmergeAppend(base, desired []any) []any {
+	var (
+		unmerged []any
+		j        int
+	)
+	for _, b := range base {
+		if j < len(desired) && reflect.DeepEqual(b, desired[j]) {
+			j++
+			continue
+		}
+		unmerged = append(unmerged, Reset{Value: b})
+	}
+	return append(unmerged, desired[j:]...)
+}
+
+// unmergeIndexed computes resets and appended items for a sequence with unicity, where an item
+// overrides the base one with the same key
+func unmergeIndexed(base, desired []any, p tree.Path, indexer IndexerFunc) ([]any, error) {
+	index := func(seq []any) (map[string]any, []string, error) {
+		items := map[string]any{}
+		keys := make([]string, len(seq))
+		for i, item := range seq {
+			key, err := indexer(item, p.Next(fmt.Sprintf("[%d]", i)))
+			if err != nil {
+				return nil, nil, err
+			}
+			items[key] = item
+			keys[i] = key
+		}
+		return items, keys, nil
+	}
+	baseItems, baseKeys, err := index(base)
+	if err != nil {
+		return nil, err
+	}
+	desiredItems, desiredKeys, err := index(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	var unmerged []any
+	for i, key := range baseKeys {
+		if _, ok := desiredItems[key]; !ok {
+			unmerged = append(unmerged, Reset{Value: base[i]})
+		}
+	}
+	for i, key := range desiredKeys {
+		if b, ok := baseItems[key]; ok && reflect.DeepEqual(b, desired[i]) {
+			continue
+		}
+		unmerged = append(unmerged, desired[i])
+	}
+	return unmerged, nil
+}
+
+// simulateMerge applies unmerged items to base, as loading the override would do. indexer enforces unicity,
+// resetIndexer identifies the base items removed by a reset
+func simulateMerge(base, unmerged []any, p tree.Path, indexer, resetIndexer IndexerFunc) ([]any, error) {
+	identify := func(indexer IndexerFunc, item any, i int) (string, error) {
+		if indexer == nil {
+			return fmt.Sprintf("%#v", item), nil
+		}
+		return indexer(item, p.Next(fmt.Sprintf("[%d]", i)))
+	}
+
+	resets := map[string]bool{}
+	var appended []any
+	for i, item := range unmerged {
+		if r, ok := item.(Reset); ok {
+			key, err := identify(resetIndexer, r.Value, i)
+			if err != nil {
+				return nil, err
+			}
+			resets[key] = true
+			continue
+		}
+		appended = append(appended, item)
+	}
+
+	var merged []any
+	keys := map[string]int{}
+	for i, item := range append(append([]any{}, base...), appended...) {
+		if i < len(base) {
+			key, err := identify(resetIndexer, item, i)
+			if err != nil {
+				return nil, err
+			}
+			if resets[key] {
+				continue
+			}
+		}
+		if indexer == nil {
+			merged = append(merged, item)
+			continue
+		}
+		key, err := indexer(item, p.Next(fmt.Sprintf("[%d]", i)))
+		if err != nil {
+			return nil, err
+		}
+		if j, ok := keys[key]; ok {
+			merged[j] = item
+		} else {
+			merged = append(merged, item)
+			keys[key] = len(merged) - 1
+		}
+	}
+	return merged, nil
+}
+
+// logging options are merged only when both define the same driver, otherwise override replaces base
+func unmergeLogging(base any, desired any, p tree.Path) (any, error) {
+	b, ok1 := base.(map[string]any)
+	d, ok2 := desired.(map[string]any)
+	if !ok1 || !ok2 {
+		return Override{Value: desired}, nil
+	}
+	driver, ok1 := d["driver"]
+	other, ok2 := b["driver"]
+	if ok1 && ok2 && driver != other {
+		return desired, nil
+	}
+	return unmergeMappings(b, d, p)
+}
+
+// environment is merged using the sequence syntax, and variables are identified by name
+func unmergeEnvironment(base any, desired any, p tree.Path) (any, error) {
+	b := environmentMapping(base, p)
+	d := environmentMapping(desired, p)
+	var unmerged []any
+	for _, entry := range convertIntoSequence(base) {
+		key, _ := environmentIndexer(entry, p)
+		if _, ok := d[key]; !ok {
+			unmerged = append(unmerged, Reset{Value: key})
+		}
+	}
+	for _, entry := range convertIntoSequence(desired) {
+		key, _ := environmentIndexer(entry, p)
+		if v, ok := b[key]; ok && v == d[key] {
+			continue
+		}
+		unmerged = append(unmerged, entry)
+	}
+	return unmerged, nil
+}
+
+// environmentMapping converts environment into a mapping of variable name to the declared entry
+func environmentMapping(value any, p tree.Path) map[string]string {
+	mapping := map[string]string{}
+	for _, entry := range convertIntoSequence(value) {
+		key, _ := environmentIndexer(entry, p)
+		mapping[key] = entry.(string)
+	}
+	return mapping
+}
+
+func replace(_ any, desired any, _ tree.Path) (any, error) {
+	return desired, nil
+}
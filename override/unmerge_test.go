@@ -0,0 +1,164 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package override
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+)
+
+func assertUnmerge(t *testing.T, base string, desired string, want string) {
+	unmerged, err := Unmerge(unmarshall(t, base), unmarshall(t, desired))
+	assert.NilError(t, err)
+	got, err := yaml.Marshal(unmerged)
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), want)
+}
+
+func Test_unmergeMappings(t *testing.T) {
+	assertUnmerge(t, `
+services:
+  test:
+    image: foo
+    user: root
+  db:
+    image: postgres
+`, `
+services:
+  test:
+    image: bar
+    working_dir: /app
+`, `services:
+    db: !reset null
+    test:
+        image: bar
+        user: !reset null
+        working_dir: /app
+`)
+}
+
+func Test_unmergeSequences(t *testing.T) {
+	assertUnmerge(t, `
+services:
+  test:
+    ports:
+      - 8080:80
+      - 9090:90
+    dns:
+      - 1.1.1.1
+    volumes:
+      - ./data:/data
+      - ./src:/src
+    command: [echo, hello]
+`, `
+services:
+  test:
+    ports:
+      - 8080:80
+      - 9443:443
+    dns:
+      - 8.8.8.8
+      - 1.1.1.1
+    volumes:
+      - ./src:/src
+      - ./other:/data
+    command: [echo, world]
+`, `services:
+    test:
+        command:
+            - echo
+            - world
+        dns:
+            - !reset 1.1.1.1
+            - 8.8.8.8
+            - 1.1.1.1
+        ports:
+            - !reset 9090:90
+            - 9443:443
+        volumes: !override
+            - ./src:/src
+            - ./other:/data
+`)
+}
+
+func Test_unmergeEnvironment(t *testing.T) {
+	assertUnmerge(t, `
+services:
+  test:
+    environment:
+      FOO: foo
+      BAR: bar
+`, `
+services:
+  test:
+    environment:
+      FOO: foo
+      ZOT: zot
+`, `services:
+    test:
+        environment:
+            - !reset BAR
+            - ZOT=zot
+`)
+}
+
+func Test_unmergeLogging(t *testing.T) {
+	assertUnmerge(t, `
+services:
+  test:
+    logging:
+      driver: json-file
+      options:
+        max-size: 10m
+`, `
+services:
+  test:
+    logging:
+      driver: syslog
+      options:
+        syslog-address: udp://localhost
+`, `services:
+    test:
+        logging:
+            driver: syslog
+            options:
+                syslog-address: udp://localhost
+`)
+}
+
+func Test_unmergePortsIdentity(t *testing.T) {
+	// reset would remove both ports, as they declare the same binding
+	assertUnmerge(t, `
+services:
+  test:
+    ports:
+      - 8080:80
+      - target: 80
+        published: "8080"
+`, `
+services:
+  test:
+    ports:
+      - 8080:80
+`, `services:
+    test:
+        ports: !override
+            - 8080:80
+`)
+}
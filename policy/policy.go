@@ -0,0 +1,218 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package policy evaluates CEL expressions against a compose project, to enforce admission policies
+package policy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/google/cel-go/cel"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ScopeService policies are evaluated for each service, with `service` and `project` variables set
+	ScopeService = "service"
+	// ScopeProject policies are evaluated once, with `project` variable set
+	ScopeProject = "project"
+)
+
+// Policy is a CEL expression a compose project must satisfy
+type Policy struct {
+	// Name identifies the policy
+	Name string `yaml:"name"`
+	// Description explains the policy, and is used as violation message
+	Description string `yaml:"description,omitempty"`
+	// Scope is either ScopeService (default) or ScopeProject
+	Scope string `yaml:"scope,omitempty"`
+	// Path is the attribute, relative to service for ScopeService, violations relate to
+	Path string `yaml:"path,omitempty"`
+	// Expression is the CEL expression, which must evaluate to true for policy to be satisfied
+	Expression string `yaml:"expression"`
+}
+
+// Violation reports a policy not satisfied by a compose project
+type Violation struct {
+	// Policy is the name of the violated policy
+	Policy string
+	// Service is the name of the service violating policy, if policy has ScopeService
+	Service string
+	// Path is the compose model element violating policy
+	Path tree.Path
+	// Message describes the violation
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return fmt.Sprintf("%s: %s", v.Policy, v.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", v.Path, v.Policy, v.Message)
+}
+
+// Load reads policies from a YAML file, declaring a list of policies
+func Load(file string) ([]Policy, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return policies, nil
+}
+
+// Parse parses policies declared as a YAML list
+func Parse(content []byte) ([]Policy, error) {
+	var policies []Policy
+	if err := yaml.Unmarshal(content, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+type program struct {
+	Policy
+	program cel.Program
+}
+
+// Evaluator evaluates a set of compiled policies
+type Evaluator struct {
+	programs []program
+}
+
+// NewEvaluator compiles policies, and returns an Evaluator to check them against compose projects
+func NewEvaluator(policies ...Policy) (*Evaluator, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("project", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("service", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	e := &Evaluator{}
+	for _, p := range policies {
+		if p.Name == "" {
+			return nil, errors.Wrap(errdefs.ErrInvalid, "policy name is required")
+		}
+		switch p.Scope {
+		case "":
+			p.Scope = ScopeService
+		case ScopeService, ScopeProject:
+		default:
+			return nil, errors.Wrapf(errdefs.ErrInvalid, "policy %s: unsupported scope %q", p.Name, p.Scope)
+		}
+		ast, issues := env.Compile(p.Expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, errors.Wrapf(errdefs.ErrInvalid, "policy %s: %s", p.Name, issues.Err())
+		}
+		if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+			return nil, errors.Wrapf(errdefs.ErrInvalid, "policy %s: expression must evaluate to a bool, got %s", p.Name, t)
+		}
+		prg, err := env.Program(ast)
+		if err != nil {
+			return nil, errors.Wrapf(err, "policy %s", p.Name)
+		}
+		e.programs = append(e.programs, program{Policy: p, program: prg})
+	}
+	return e, nil
+}
+
+// Evaluate checks policies against project, and returns violations. A policy which fails to evaluate, for example
+// because expression accesses an attribute not set by a service, is reported as a violation so that other policies
+// still get checked
+func (e *Evaluator) Evaluate(project *types.Project) ([]Violation, error) {
+	projectView := view(project)
+	names := project.ServiceNames()
+	sort.Strings(names)
+
+	var violations []Violation
+	for _, p := range e.programs {
+		if p.Scope == ScopeProject {
+			ok, err := p.eval(map[string]any{"project": projectView})
+			path := tree.NewPath(pathParts(p.Path)...)
+			if err != nil {
+				violations = append(violations, p.failure("", path, err))
+			} else if !ok {
+				violations = append(violations, p.violation("", path))
+			}
+			continue
+		}
+		for _, name := range names {
+			ok, err := p.eval(map[string]any{
+				"project": projectView,
+				"service": view(project.Services[name]),
+			})
+			path := tree.NewPath(append([]string{"services", name}, pathParts(p.Path)...)...)
+			if err != nil {
+				violations = append(violations, p.failure(name, path, err))
+			} else if !ok {
+				violations = append(violations, p.violation(name, path))
+			}
+		}
+	}
+	return violations, nil
+}
+
+func (p program) eval(vars map[string]any) (bool, error) {
+	out, _, err := p.program.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	ok, isBool := out.Value().(bool)
+	if !isBool {
+		return false, errors.Errorf("expression must evaluate to a bool, got %v", out.Type())
+	}
+	return ok, nil
+}
+
+func (p program) violation(service string, path tree.Path) Violation {
+	message := p.Description
+	if message == "" {
+		message = fmt.Sprintf("expression %q is not satisfied", p.Expression)
+	}
+	return Violation{
+		Policy:  p.Name,
+		Service: service,
+		Path:    path,
+		Message: message,
+	}
+}
+
+// failure reports a policy which could not be evaluated
+func (p program) failure(service string, path tree.Path, err error) Violation {
+	return Violation{
+		Policy:  p.Name,
+		Service: service,
+		Path:    path,
+		Message: fmt.Sprintf("failed to evaluate expression: %s", err),
+	}
+}
+
+func pathParts(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return tree.Path(path).Parts()
+}
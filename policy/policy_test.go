@@ -0,0 +1,154 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func load(t *testing.T, yaml string) *types.Project {
+	t.Helper()
+	project, err := loader.LoadWithContext(context.TODO(), types.ConfigDetails{
+		ConfigFiles: []types.ConfigFile{{Filename: "compose.yaml", Content: []byte(yaml)}},
+		Environment: map[string]string{},
+	}, func(options *loader.Options) {
+		options.SetProjectName("policy", true)
+	})
+	assert.NilError(t, err)
+	return project
+}
+
+func TestEvaluate(t *testing.T) {
+	policies, err := Load("testdata/policies.yaml")
+	assert.NilError(t, err)
+	evaluator, err := NewEvaluator(policies...)
+	assert.NilError(t, err)
+
+	violations, err := evaluator.Evaluate(load(t, `
+services:
+  web:
+    image: nginx
+    ports:
+      - 80:80
+      - 8443:443
+    healthcheck:
+      interval: 5s
+  app:
+    image: app
+    ports:
+      - 8080
+  db:
+    image: postgres
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, violations, []Violation{
+		{
+			Policy:  "unprivileged-ports",
+			Service: "web",
+			Path:    "services.web.ports",
+			Message: "services must not publish privileged ports",
+		},
+		{
+			Policy:  "healthcheck-interval",
+			Service: "web",
+			Path:    "services.web.healthcheck.interval",
+			Message: "healthcheck must not run more than every 10s",
+		},
+		{
+			Policy:  "owner-label",
+			Path:    "x-owner",
+			Message: "project must declare an owner",
+		},
+	})
+}
+
+func TestEvaluateProjectExtensions(t *testing.T) {
+	evaluator, err := NewEvaluator(Policy{
+		Name:       "owner",
+		Scope:      ScopeProject,
+		Expression: `project["x-owner"] == "team-a" && project.services.all(s, "image" in project.services[s])`,
+	})
+	assert.NilError(t, err)
+	violations, err := evaluator.Evaluate(load(t, `
+x-owner: team-a
+services:
+  web:
+    image: nginx
+`))
+	assert.NilError(t, err)
+	assert.Equal(t, len(violations), 0)
+}
+
+func TestEvaluateRuntimeError(t *testing.T) {
+	evaluator, err := NewEvaluator(Policy{
+		Name:       "healthcheck",
+		Path:       "healthcheck.interval",
+		Expression: `service.healthcheck.interval >= duration("10s")`,
+	}, Policy{
+		Name:       "image",
+		Expression: `service.image.startsWith("registry.example.com/")`,
+	})
+	assert.NilError(t, err)
+	violations, err := evaluator.Evaluate(load(t, `
+services:
+  app:
+    image: app
+    healthcheck:
+      interval: 30s
+  web:
+    image: nginx
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, violations, []Violation{
+		{
+			Policy:  "healthcheck",
+			Service: "web",
+			Path:    "services.web.healthcheck.interval",
+			Message: "failed to evaluate expression: no such key: healthcheck",
+		},
+		{
+			Policy:  "image",
+			Service: "app",
+			Path:    "services.app",
+			Message: `expression "service.image.startsWith(\"registry.example.com/\")" is not satisfied`,
+		},
+		{
+			Policy:  "image",
+			Service: "web",
+			Path:    "services.web",
+			Message: `expression "service.image.startsWith(\"registry.example.com/\")" is not satisfied`,
+		},
+	})
+}
+
+func TestInvalidPolicy(t *testing.T) {
+	_, err := NewEvaluator(Policy{Name: "invalid", Expression: "service.image +"})
+	assert.ErrorIs(t, err, errdefs.ErrInvalid)
+	assert.ErrorContains(t, err, "policy invalid")
+
+	_, err = NewEvaluator(Policy{Name: "not-bool", Expression: `"foo"`})
+	assert.ErrorContains(t, err, "policy not-bool: expression must evaluate to a bool, got string")
+
+	_, err = NewEvaluator(Policy{Name: "scope", Scope: "network", Expression: "true"})
+	assert.ErrorContains(t, err, `policy scope: unsupported scope "network"`)
+}
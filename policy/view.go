@@ -0,0 +1,123 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+var (
+	durationType = reflect.TypeOf(types.Duration(0))
+	nanoCPUsType = reflect.TypeOf(types.NanoCPUs(0))
+)
+
+// view converts a compose model value into the representation exposed to CEL expressions. Attributes are keyed by
+// their name in compose file, extensions included. Unlike the yaml representation, empty attributes are kept so
+// that expressions don't have to check for their presence, except for optional sections (like `build` or
+// `healthcheck`) which can be checked using `has()`. Durations are exposed as CEL durations, and CPUs as a
+// (decimal) number of CPUs.
+func view(value any) any {
+	return viewOf(reflect.ValueOf(value))
+}
+
+func viewOf(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int())
+	case nanoCPUsType:
+		return types.NanoCPUs(v.Int()).Value()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return viewOf(v.Elem())
+	case reflect.Struct:
+		m := map[string]any{}
+		structView(v, m)
+		return m
+	case reflect.Map:
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = viewOf(iter.Value())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		l := make([]any, v.Len())
+		for i := range l {
+			l[i] = viewOf(v.Index(i))
+		}
+		return l
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	default:
+		return nil
+	}
+}
+
+// structView sets struct fields into m, keyed by yaml attribute name
+func structView(v reflect.Value, m map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if strings.Contains(opts, "inline") {
+			switch fv.Kind() {
+			case reflect.Map:
+				for k, e := range viewOf(fv).(map[string]any) {
+					m[k] = e
+				}
+			case reflect.Struct:
+				structView(fv, m)
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if fv.Kind() == reflect.Pointer && fv.IsNil() {
+			// optional section, can be checked with has()
+			continue
+		}
+		m[name] = viewOf(fv)
+	}
+}
@@ -20,9 +20,13 @@ import (
 	// Enable support for embedded static resources
 	_ "embed"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/compose-spec/compose-go/v2/internal/pathrules"
+	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/xeipuuv/gojsonschema"
 )
 
@@ -77,6 +81,120 @@ func toError(result *gojsonschema.Result) error {
 	return err
 }
 
+// extensions are the JSON schemas registered to validate extensions, by path pattern
+var extensions pathrules.Rules[*gojsonschema.Schema]
+
+// RegisterExtension registers a JSON schema to validate an extension. Extension is set by a path pattern, using `*`
+// to match any key and `[]` to match list items, like `services.*.x-traefik`. ValidateExtensions then checks extension
+// declared by compose model against schema. When patterns overlap, the schema registered with the most specific
+// pattern applies.
+// RegisterExtension is designed to be called during program initialization, and is not safe for concurrent use.
+func RegisterExtension(path string, schema string) error {
+	pattern := tree.Path(path)
+	if !strings.HasPrefix(pattern.Last(), "x-") {
+		return fmt.Errorf("invalid extension path %q, must end with an x- attribute", path)
+	}
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+	if err != nil {
+		return fmt.Errorf("invalid JSON schema for extension %s: %w", path, err)
+	}
+	extensions.Register(pattern, compiled)
+	return nil
+}
+
+// ValidateExtensions checks extensions declared by config against the schemas set by RegisterExtension. As an override
+// file can declare only part of an extension, config must be the merged compose model.
+func ValidateExtensions(config map[string]interface{}) error {
+	if extensions.Len() == 0 {
+		return nil
+	}
+	return validateExtensions(config, tree.NewPath(), tree.NewPath())
+}
+
+// validateExtensions checks extensions declared within node. pattern is the path to node with list items as `[]`,
+// to select the registered schema, while path is the actual one
+func validateExtensions(node interface{}, pattern tree.Path, path tree.Path) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := validateExtension(n[k], pattern.Next(k), path.Next(k)); err != nil {
+				return err
+			}
+			if err := validateExtensions(n[k], pattern.Next(k), path.Next(k)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, v := range n {
+			if err := validateExtensions(v, pattern.Next(tree.PathMatchList), path.Next(strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateExtension checks value set at path against the schema registered for pattern, if any
+func validateExtension(value interface{}, pattern tree.Path, path tree.Path) error {
+	if !strings.HasPrefix(pattern.Last(), "x-") {
+		return nil
+	}
+	schema, ok := extensions.Match(pattern)
+	if !ok {
+		return nil
+	}
+	result, err := schema.Validate(gojsonschema.NewGoLoader(value))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if !result.Valid() {
+		err := getMostSpecificError(result.Errors())
+		err.path = path
+		return err
+	}
+	return nil
+}
+
+// lookup returns values within node set by path pattern parts, by their actual path
+func lookup(node interface{}, path tree.Path, parts []string) map[tree.Path]interface{} {
+	if len(parts) == 0 {
+		return map[tree.Path]interface{}{path: node}
+	}
+	values := map[tree.Path]interface{}{}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		keys := []string{parts[0]}
+		if parts[0] == tree.PathMatchAll {
+			keys = keys[:0]
+			for k := range n {
+				keys = append(keys, k)
+			}
+		}
+		for _, k := range keys {
+			if v, ok := n[k]; ok {
+				for p, found := range lookup(v, path.Next(k), parts[1:]) {
+					values[p] = found
+				}
+			}
+		}
+	case []interface{}:
+		if parts[0] != tree.PathMatchList {
+			return nil
+		}
+		for i, v := range n {
+			for p, found := range lookup(v, path.Next(strconv.Itoa(i)), parts[1:]) {
+				values[p] = found
+			}
+		}
+	}
+	return values
+}
+
 const (
 	jsonschemaOneOf = "number_one_of"
 	jsonschemaAnyOf = "number_any_of"
@@ -121,11 +239,21 @@ func humanReadableType(definition string) string {
 type validationError struct {
 	parent gojsonschema.ResultError
 	child  gojsonschema.ResultError
+	// path is the extension being validated, as fields are relative to extension schema
+	path tree.Path
 }
 
 func (err validationError) Error() string {
 	description := getDescription(err)
-	return fmt.Sprintf("%s %s", err.parent.Field(), description)
+	field := err.parent.Field()
+	if err.path != "" {
+		if field == "(root)" {
+			field = string(err.path)
+		} else {
+			field = string(err.path.Next(field))
+		}
+	}
+	return fmt.Sprintf("%s %s", field, description)
 }
 
 func getMostSpecificError(errors []gojsonschema.ResultError) validationError {
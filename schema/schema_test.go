@@ -233,3 +233,54 @@ func TestValidateRollbackConfigWithUpdateConfigFull(t *testing.T) {
 	assert.NilError(t, Validate(config))
 	assert.NilError(t, Validate(config))
 }
+
+func TestValidateRegisteredExtension(t *testing.T) {
+	registered := extensions.Clone()
+	defer func() {
+		extensions = registered
+	}()
+	err := RegisterExtension("services.*.x-traefik", `{
+  "type": "object",
+  "properties": {
+    "port": {"type": "integer"},
+    "rule": {"type": "string"}
+  },
+  "required": ["rule"],
+  "additionalProperties": false
+}`)
+	assert.NilError(t, err)
+
+	// as loaded from yaml, without the dict type
+	foo := map[string]interface{}{
+		"image":     "busybox",
+		"x-traefik": map[string]interface{}{"rule": "Host(`foo`)", "port": 8080},
+	}
+	config := map[string]interface{}{
+		"services": map[string]interface{}{
+			"foo": foo,
+			"bar": map[string]interface{}{"image": "busybox"},
+		},
+	}
+	assert.NilError(t, ValidateExtensions(config))
+
+	foo["x-traefik"] = map[string]interface{}{"rule": "Host(`foo`)", "port": "http"}
+	err = ValidateExtensions(config)
+	assert.Error(t, err, "services.foo.x-traefik.port must be a integer")
+
+	foo["x-traefik"] = map[string]interface{}{"rul": "Host(`foo`)"}
+	err = ValidateExtensions(config)
+	assert.ErrorContains(t, err, "services.foo.x-traefik")
+	assert.ErrorContains(t, err, "rul")
+
+	// most specific pattern applies
+	assert.NilError(t, RegisterExtension("services.foo.x-traefik", `{"type": "object"}`))
+	assert.NilError(t, ValidateExtensions(config))
+}
+
+func TestRegisterExtensionInvalid(t *testing.T) {
+	err := RegisterExtension("services.*.traefik", `{}`)
+	assert.ErrorContains(t, err, "must end with an x- attribute")
+
+	err = RegisterExtension("services.*.x-traefik", `{"type": 12}`)
+	assert.ErrorContains(t, err, "invalid JSON schema for extension services.*.x-traefik")
+}
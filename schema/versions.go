@@ -0,0 +1,199 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Feature is an attribute or a YAML tag of the Compose Specification which is not supported by all implementations
+type Feature struct {
+	// Path is the attribute pattern, using `*` to match any key and `[]` to match list items
+	Path tree.Path
+	// Tag is the YAML tag, for features set by tagging a node rather than by an attribute
+	Tag string
+	// Version is the first Docker Compose release supporting feature
+	Version string
+}
+
+// Features are the attributes and tags introduced after the initial release of the Compose Specification, ordered
+// by version. Attributes declared by the embedded schema must be either listed here, or be part of the initial
+// release (see testdata/baseline.txt)
+var Features = []Feature{
+	{Path: "services.*.build.platforms", Version: "2.11.0"},
+	{Path: "services.*.build.tags", Version: "2.11.0"},
+	{Path: "services.*.build.privileged", Version: "2.15.0"},
+	{Path: "services.*.build.additional_contexts", Version: "2.17.0"},
+	{Path: "services.*.build.dockerfile_inline", Version: "2.17.0"},
+	{Path: "services.*.depends_on.*.restart", Version: "2.17.0"},
+	{Path: "include", Version: "2.20.0"},
+	{Path: "services.*.annotations", Version: "2.20.0"},
+	{Path: "services.*.attach", Version: "2.20.0"},
+	{Path: "services.*.depends_on.*.required", Version: "2.20.0"},
+	{Path: "services.*.healthcheck.start_interval", Version: "2.20.2"},
+	{Path: "services.*.develop", Version: "2.22.0"},
+	{Path: "configs.*.content", Version: "2.23.1"},
+	{Path: "configs.*.environment", Version: "2.23.1"},
+	{Path: "services.*.env_file.[].required", Version: "2.24.0"},
+	{Tag: "!reset", Version: "2.24.0"},
+	{Tag: "!override", Version: "2.24.4"},
+	{Path: "services.*.env_file.[].format", Version: "2.30.0"},
+}
+
+// FeatureUsage is a Feature used by a compose model
+type FeatureUsage struct {
+	Feature
+	// Path is the actual attribute using feature, or the node set with the feature tag
+	Path tree.Path
+}
+
+func (u FeatureUsage) String() string {
+	if u.Tag != "" {
+		return fmt.Sprintf("%s %s", u.Tag, u.Path)
+	}
+	return string(u.Path)
+}
+
+// UsedFeatures returns the attributes used by compose model which are features, sorted by path
+func UsedFeatures(config map[string]interface{}) []FeatureUsage {
+	var used []FeatureUsage
+	for _, feature := range Features {
+		if feature.Tag != "" {
+			continue
+		}
+		for path := range lookup(config, "", feature.Path.Parts()) {
+			used = append(used, FeatureUsage{Feature: feature, Path: path})
+		}
+	}
+	sortUsages(used)
+	return used
+}
+
+// UsedTags returns the YAML tags set by nodes of a compose file which are features, sorted by path. Sequence items
+// are identified by their index.
+func UsedTags(node *yaml.Node) []FeatureUsage {
+	var used []FeatureUsage
+	var walk func(node *yaml.Node, path tree.Path)
+	walk = func(node *yaml.Node, path tree.Path) {
+		for _, feature := range Features {
+			if feature.Tag != "" && feature.Tag == node.Tag {
+				used = append(used, FeatureUsage{Feature: feature, Path: path})
+			}
+		}
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, n := range node.Content {
+				walk(n, path)
+			}
+		case yaml.SequenceNode:
+			for i, n := range node.Content {
+				walk(n, path.Next(strconv.Itoa(i)))
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], path.Next(node.Content[i].Value))
+			}
+		}
+	}
+	walk(node, tree.NewPath())
+	sortUsages(used)
+	return used
+}
+
+func sortUsages(used []FeatureUsage) {
+	sort.SliceStable(used, func(i, j int) bool {
+		return used[i].Path < used[j].Path
+	})
+}
+
+// MinimumVersion returns the first version supporting all features used by compose model, or an empty string if
+// model only uses attributes supported by all versions
+func MinimumVersion(config map[string]interface{}) string {
+	return MinimumFeaturesVersion(UsedFeatures(config))
+}
+
+// MinimumFeaturesVersion returns the first version supporting all used features, or an empty string if none is used
+func MinimumFeaturesVersion(used []FeatureUsage) string {
+	minimum := ""
+	for _, u := range used {
+		if minimum == "" || compareVersions(u.Version, minimum) > 0 {
+			minimum = u.Version
+		}
+	}
+	return minimum
+}
+
+// CheckVersion checks compose model only uses features supported by target version
+func CheckVersion(config map[string]interface{}, target string) error {
+	return CheckFeaturesVersion(UsedFeatures(config), target)
+}
+
+// CheckFeaturesVersion checks used features are supported by target version
+func CheckFeaturesVersion(used []FeatureUsage, target string) error {
+	if _, err := parseVersion(target); err != nil {
+		return err
+	}
+	var unsupported []string
+	for _, u := range used {
+		if compareVersions(u.Version, target) > 0 {
+			unsupported = append(unsupported, fmt.Sprintf("%s requires version %s", u, u.Version))
+		}
+	}
+	if len(unsupported) > 0 {
+		return errors.Wrapf(errdefs.ErrUnsupported, "target version %s doesn't support:\n%s", target,
+			strings.Join(unsupported, "\n"))
+	}
+	return nil
+}
+
+// parseVersion parses a `major.minor.patch` version, minor and patch being optional
+func parseVersion(version string) ([3]int, error) {
+	var parsed [3]int
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) > len(parsed) {
+		return parsed, fmt.Errorf("invalid version %q", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, fmt.Errorf("invalid version %q", version)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
+
+// compareVersions returns a negative number if a < b, a positive number if a > b, and 0 if a == b. Versions must
+// be valid.
+func compareVersions(a, b string) int {
+	va, _ := parseVersion(a)
+	vb, _ := parseVersion(b)
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] - vb[i]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,187 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestMinimumVersion(t *testing.T) {
+	config := map[string]interface{}{
+		"include": []interface{}{"other.yaml"},
+		"services": map[string]interface{}{
+			"web": map[string]interface{}{
+				"image": "busybox",
+				"healthcheck": map[string]interface{}{
+					"start_interval": "1s",
+				},
+			},
+			"db": map[string]interface{}{
+				"image": "busybox",
+			},
+		},
+	}
+	used := UsedFeatures(config)
+	assert.Equal(t, len(used), 2)
+	assert.Equal(t, used[0].Path, tree.Path("include"))
+	assert.Equal(t, used[1].Path, tree.Path("services.web.healthcheck.start_interval"))
+	assert.Equal(t, MinimumVersion(config), "2.20.2")
+
+	assert.Equal(t, MinimumVersion(map[string]interface{}{}), "")
+}
+
+func TestCheckVersion(t *testing.T) {
+	config := map[string]interface{}{
+		"services": map[string]interface{}{
+			"web": map[string]interface{}{
+				"image":       "busybox",
+				"annotations": map[string]interface{}{"foo": "bar"},
+				"develop":     map[string]interface{}{},
+			},
+		},
+	}
+	assert.NilError(t, CheckVersion(config, "2.22.0"))
+	assert.NilError(t, CheckVersion(config, "v2.23"))
+
+	err := CheckVersion(config, "2.20")
+	assert.Check(t, errdefs.IsUnsupportedError(err))
+	assert.Error(t, err, "target version 2.20 doesn't support:\n"+
+		"services.web.develop requires version 2.22.0: unsupported attribute")
+
+	err = CheckVersion(config, "2.17.0")
+	assert.ErrorContains(t, err, "services.web.annotations requires version 2.20.0\n")
+
+	err = CheckVersion(config, "latest")
+	assert.Error(t, err, `invalid version "latest"`)
+}
+
+func TestUsedTags(t *testing.T) {
+	var node yaml.Node
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+services:
+  web:
+    image: busybox
+    ports: !override
+      - 8080:80
+    environment:
+      - FOO=bar
+      - !reset BAR=baz
+    labels: !reset {}
+`), &node))
+	used := UsedTags(&node)
+	assert.Equal(t, len(used), 3)
+	assert.Equal(t, used[0].String(), "!reset services.web.environment.1")
+	assert.Equal(t, used[1].String(), "!reset services.web.labels")
+	assert.Equal(t, used[2].String(), "!override services.web.ports")
+	assert.Equal(t, MinimumFeaturesVersion(used), "2.24.4")
+
+	err := CheckFeaturesVersion(used, "2.24.0")
+	assert.Check(t, errdefs.IsUnsupportedError(err))
+	assert.Error(t, err, "target version 2.24.0 doesn't support:\n"+
+		"!override services.web.ports requires version 2.24.4: unsupported attribute")
+}
+
+// TestFeaturesCoverSchema checks attributes declared by the embedded schema are either supported by the initial
+// release, or listed as a feature, so that the table gets updated with the schema
+func TestFeaturesCoverSchema(t *testing.T) {
+	content, err := os.ReadFile("testdata/baseline.txt")
+	assert.NilError(t, err)
+	baseline := map[string]bool{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			baseline[line] = true
+		}
+	}
+
+	var root map[string]any
+	assert.NilError(t, json.Unmarshal([]byte(Schema), &root))
+	attributes := map[string]bool{}
+	schemaAttributes(root, root["definitions"].(map[string]any), "", attributes, map[string]bool{})
+
+	var missing []string
+	for attribute := range attributes {
+		if !baseline[attribute] && !isFeature(tree.Path(attribute)) {
+			missing = append(missing, attribute)
+		}
+	}
+	sort.Strings(missing)
+	assert.Check(t, is.Len(missing, 0), "attributes neither in testdata/baseline.txt nor in Features: %v", missing)
+
+	for attribute := range baseline {
+		assert.Check(t, attributes[attribute], "%s is not declared by schema", attribute)
+	}
+}
+
+// isFeature checks attribute, or one of its parents, is listed as a feature
+func isFeature(attribute tree.Path) bool {
+	for _, feature := range Features {
+		if feature.Tag == "" && (attribute == feature.Path || strings.HasPrefix(string(attribute), string(feature.Path)+".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaAttributes collects the attributes declared by a JSON schema node, as path patterns
+func schemaAttributes(node map[string]any, definitions map[string]any, path tree.Path, attributes map[string]bool, visiting map[string]bool) {
+	if ref, ok := node["$ref"].(string); ok {
+		name := ref[strings.LastIndex(ref, "/")+1:]
+		key := name + "@" + string(path)
+		if visiting[key] {
+			return
+		}
+		visiting[key] = true
+		defer delete(visiting, key)
+		schemaAttributes(definitions[name].(map[string]any), definitions, path, attributes, visiting)
+		return
+	}
+	if properties, ok := node["properties"].(map[string]any); ok {
+		for name, property := range properties {
+			attributes[string(path.Next(name))] = true
+			schemaAttributes(property.(map[string]any), definitions, path.Next(name), attributes, visiting)
+		}
+	}
+	if patterns, ok := node["patternProperties"].(map[string]any); ok {
+		for pattern, property := range patterns {
+			if !strings.HasPrefix(pattern, "^x-") {
+				schemaAttributes(property.(map[string]any), definitions, path.Next(tree.PathMatchAll), attributes, visiting)
+			}
+		}
+	}
+	if additional, ok := node["additionalProperties"].(map[string]any); ok {
+		schemaAttributes(additional, definitions, path.Next(tree.PathMatchAll), attributes, visiting)
+	}
+	if items, ok := node["items"].(map[string]any); ok {
+		schemaAttributes(items, definitions, path.Next(tree.PathMatchList), attributes, visiting)
+	}
+	for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+		alternatives, _ := node[keyword].([]any)
+		for _, alternative := range alternatives {
+			schemaAttributes(alternative.(map[string]any), definitions, path, attributes, visiting)
+		}
+	}
+}
@@ -89,6 +89,7 @@ type Config struct {
 	substituteFunc  SubstituteFunc
 	replacementFunc ReplacementFunc
 	logging         bool
+	unsetFunc       func(variable string)
 }
 
 type Option func(*Config)
@@ -115,6 +116,13 @@ func WithoutLogging(cfg *Config) {
 	cfg.logging = false
 }
 
+// WithUnsetVariableFunction sets the function notified about variables not being set, instead of logging a warning
+func WithUnsetVariableFunction(unsetFunc func(variable string)) Option {
+	return func(cfg *Config) {
+		cfg.unsetFunc = unsetFunc
+	}
+}
+
 // SubstituteWithOptions substitute variables in the string with their values.
 // It accepts additional options such as a custom function or pattern.
 func SubstituteWithOptions(template string, mapping Mapping, options ...Option) (string, error) {
@@ -203,7 +211,11 @@ func DefaultReplacementAppliedFunc(substring string, mapping Mapping, cfg *Confi
 
 	value, ok := mapping(substitution)
 	if !ok && cfg.logging {
-		logrus.Warnf("The %q variable is not set. Defaulting to a blank string.", substitution)
+		if cfg.unsetFunc != nil {
+			cfg.unsetFunc(substitution)
+		} else {
+			logrus.Warnf("The %q variable is not set. Defaulting to a blank string.", substitution)
+		}
 	}
 
 	return value, ok, nil
@@ -0,0 +1,257 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package transform
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/consts"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v3"
+)
+
+// compactors compute the short syntax for an attribute. Short syntax is only used if it converts
+// back into the same canonical representation
+var compactors = map[tree.Path]transformFunc{}
+
+func init() {
+	compactors["services.*.build"] = compactBuild
+	compactors["services.*.depends_on"] = compactDependsOn
+	compactors["services.*.networks"] = compactServiceNetworks
+	compactors["services.*.volumes.*"] = compactVolumeMount
+	compactors["services.*.secrets.*"] = compactFileMount
+	compactors["services.*.configs.*"] = compactFileMount
+	compactors["services.*.ports.*"] = compactPort
+}
+
+// Compact transforms a compose model into the shortest equivalent syntax, as the inverse of Canonical.
+// Short syntax is used wherever it is lossless
+func Compact(yaml map[string]any) (map[string]any, error) {
+	compact, err := compact(yaml, tree.NewPath())
+	if err != nil {
+		return nil, err
+	}
+	return compact.(map[string]any), nil
+}
+
+func compact(data any, p tree.Path) (any, error) {
+	for pattern, compactor := range compactors {
+		if p.Matches(pattern) {
+			return compactor(data, p)
+		}
+	}
+	switch v := data.(type) {
+	case map[string]any:
+		for k, e := range v {
+			c, err := compact(e, p.Next(k))
+			if err != nil {
+				return nil, err
+			}
+			v[k] = c
+		}
+		return v, nil
+	case []any:
+		for i, e := range v {
+			c, err := compact(e, p.Next("[]"))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = c
+		}
+		return v, nil
+	default:
+		return data, nil
+	}
+}
+
+// isEquivalent checks data is the canonical representation computed for a short syntax
+func isEquivalent(data any, canonical func() (any, error)) bool {
+	c, err := canonical()
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(normalize(c), normalize(data))
+}
+
+// normalize converts value into types as parsed from yaml, without extensions nor empty attributes, so that
+// values produced by encoding go types can be compared
+func normalize(value any) any {
+	b, err := yaml.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized any
+	if err := yaml.Unmarshal(b, &normalized); err != nil {
+		return value
+	}
+	return withoutExtensions(normalized)
+}
+
+func withoutExtensions(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		delete(v, consts.Extensions)
+		for k, e := range v {
+			if e = withoutExtensions(e); e == nil {
+				delete(v, k)
+			} else {
+				v[k] = e
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = withoutExtensions(e)
+		}
+	}
+	return value
+}
+
+func compactBuild(data any, p tree.Path) (any, error) {
+	v, ok := data.(map[string]any)
+	if !ok || len(v) != 1 {
+		return data, nil
+	}
+	context, ok := v["context"].(string)
+	if !ok {
+		return data, nil
+	}
+	if isEquivalent(data, func() (any, error) { return transformBuild(context, p) }) {
+		return context, nil
+	}
+	return data, nil
+}
+
+func compactDependsOn(data any, p tree.Path) (any, error) {
+	v, ok := data.(map[string]any)
+	if !ok {
+		return data, nil
+	}
+	services := make([]any, 0, len(v))
+	for name := range v {
+		services = append(services, name)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].(string) < services[j].(string)
+	})
+	if isEquivalent(data, func() (any, error) { return transformDependsOn(services, p) }) {
+		return services, nil
+	}
+	return data, nil
+}
+
+func compactServiceNetworks(data any, p tree.Path) (any, error) {
+	v, ok := data.(map[string]any)
+	if !ok {
+		return data, nil
+	}
+	networks := make([]any, 0, len(v))
+	for name, config := range v {
+		if config != nil {
+			return data, nil
+		}
+		networks = append(networks, name)
+	}
+	sort.Slice(networks, func(i, j int) bool {
+		return networks[i].(string) < networks[j].(string)
+	})
+	if isEquivalent(data, func() (any, error) { return transformServiceNetworks(networks, p) }) {
+		return networks, nil
+	}
+	return data, nil
+}
+
+func compactVolumeMount(data any, p tree.Path) (any, error) {
+	v, ok := data.(map[string]any)
+	if !ok {
+		return data, nil
+	}
+	var volume types.ServiceVolumeConfig
+	if err := decode(v, &volume); err != nil {
+		return data, nil
+	}
+	// read-write access is the default and can be omitted
+	short := volume.String()
+	candidates := []string{
+		strings.Replace(strings.TrimSuffix(short, ":rw"), ":rw,", ":", 1),
+		short,
+	}
+	for _, candidate := range candidates {
+		if isEquivalent(data, func() (any, error) { return transformVolumeMount(candidate, p) }) {
+			return candidate, nil
+		}
+	}
+	return data, nil
+}
+
+func compactFileMount(data any, p tree.Path) (any, error) {
+	v, ok := data.(map[string]any)
+	if !ok || len(v) != 1 {
+		return data, nil
+	}
+	source, ok := v["source"].(string)
+	if !ok {
+		return data, nil
+	}
+	if isEquivalent(data, func() (any, error) { return transformFileMount(source, p) }) {
+		return source, nil
+	}
+	return data, nil
+}
+
+func compactPort(data any, p tree.Path) (any, error) {
+	v, ok := data.(map[string]any)
+	if !ok {
+		return data, nil
+	}
+	short := fmt.Sprint(v["target"])
+	published, _ := v["published"].(string)
+	if hostIP, ok := v["host_ip"].(string); ok && hostIP != "" {
+		if strings.Contains(hostIP, ":") {
+			hostIP = fmt.Sprintf("[%s]", hostIP)
+		}
+		short = fmt.Sprintf("%s:%s:%s", hostIP, published, short)
+	} else if published != "" {
+		short = fmt.Sprintf("%s:%s", published, short)
+	}
+	if protocol, ok := v["protocol"].(string); ok && protocol != "tcp" {
+		short = fmt.Sprintf("%s/%s", short, protocol)
+	}
+	if isEquivalent([]any{data}, func() (any, error) { return transformPorts([]any{short}, p.Parent()) }) {
+		return short, nil
+	}
+	return data, nil
+}
+
+func decode(data any, target any) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           target,
+		TagName:          "yaml",
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(data)
+}
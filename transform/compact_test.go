@@ -0,0 +1,122 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package transform
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+)
+
+func TestCompact(t *testing.T) {
+	var model map[string]any
+	err := yaml.Unmarshal([]byte(`
+services:
+  web:
+    build:
+      context: ./web
+    ports:
+      - mode: ingress
+        target: 80
+        published: "8080"
+        protocol: tcp
+      - mode: ingress
+        host_ip: 127.0.0.1
+        target: 53
+        published: "5353"
+        protocol: udp
+      - mode: host
+        target: 443
+        published: "443"
+        protocol: tcp
+    volumes:
+      - type: bind
+        source: ./data
+        target: /data
+        read_only: true
+        bind:
+          create_host_path: true
+      - type: bind
+        source: ./src
+        target: /src
+        bind:
+          selinux: z
+          create_host_path: true
+      - type: tmpfs
+        target: /tmp
+    depends_on:
+      db:
+        condition: service_started
+        required: true
+    networks:
+      front:
+      back:
+    secrets:
+      - source: key
+  worker:
+    depends_on:
+      db:
+        condition: service_healthy
+        required: true
+    networks:
+      front:
+        aliases: [worker]
+    secrets:
+      - source: key
+        target: /etc/key
+`), &model)
+	assert.NilError(t, err)
+
+	compact, err := Compact(model)
+	assert.NilError(t, err)
+
+	var expected map[string]any
+	err = yaml.Unmarshal([]byte(`
+services:
+  web:
+    build: ./web
+    ports:
+      - "8080:80"
+      - "127.0.0.1:5353:53/udp"
+      - mode: host
+        target: 443
+        published: "443"
+        protocol: tcp
+    volumes:
+      - ./data:/data:ro
+      - ./src:/src:z
+      - type: tmpfs
+        target: /tmp
+    depends_on: [db]
+    networks: [back, front]
+    secrets: [key]
+  worker:
+    depends_on:
+      db:
+        condition: service_healthy
+        required: true
+    networks:
+      front:
+        aliases: [worker]
+    secrets:
+      - source: key
+        target: /etc/key
+`), &expected)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, compact, expected)
+}
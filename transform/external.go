@@ -20,7 +20,6 @@ import (
 	"fmt"
 
 	"github.com/compose-spec/compose-go/v2/tree"
-	"github.com/sirupsen/logrus"
 )
 
 func transformMaybeExternal(data any, p tree.Path) (any, error) {
@@ -37,7 +36,6 @@ func transformMaybeExternal(data any, p tree.Path) (any, error) {
 		if external, ok := ext.(map[string]any); ok {
 			resource["external"] = true
 			if extname, extNamed := external["name"]; extNamed {
-				logrus.Warnf("%s: external.name is deprecated. Please set name and external: true", p)
 				if named && extname != name {
 					return nil, fmt.Errorf("%s: name and external.name conflict; only use name", p)
 				}
@@ -18,10 +18,11 @@ package types
 
 import (
 	"encoding/json"
+	"reflect"
 	"runtime"
 	"strings"
 
-	"github.com/mitchellh/mapstructure"
+	"github.com/compose-spec/compose-go/v2/internal/decode"
 )
 
 var (
@@ -126,10 +127,19 @@ func (c Config) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m)
 }
 
+// Get decodes extension set by name into target, which must be a pointer, and returns false if extension is not
+// declared. Decoding relies on the same rules as compose model, so that types implementing DecodeMapstructure can
+// be used. An extension already decoded by loader (see loader.RegisterExtension) is copied into target.
 func (e Extensions) Get(name string, target interface{}) (bool, error) {
-	if v, ok := e[name]; ok {
-		err := mapstructure.Decode(v, target)
-		return true, err
+	v, ok := e[name]
+	if !ok {
+		return false, nil
 	}
-	return false, nil
+	if t := reflect.ValueOf(target); t.Kind() == reflect.Ptr && !t.IsNil() && v != nil {
+		if value := reflect.ValueOf(v); value.Type().AssignableTo(t.Elem().Type()) {
+			t.Elem().Set(value)
+			return true, nil
+		}
+	}
+	return true, decode.Decode(v, target)
 }
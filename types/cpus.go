@@ -0,0 +1,101 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const nanoCPUsPerCPU = 1e9
+
+// NanoCPUs is a quantity of CPUs, in units of 10^-9 CPUs
+type NanoCPUs int64
+
+// ParseNanoCPUs parses a decimal number of CPUs, like `0.5`
+func ParseNanoCPUs(value string) (NanoCPUs, error) {
+	cpus, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return 0, fmt.Errorf("invalid number of CPUs %q", value)
+	}
+	if cpus.Sign() < 0 {
+		return 0, fmt.Errorf("number of CPUs can't be negative: %q", value)
+	}
+	nano := cpus.Mul(cpus, big.NewRat(nanoCPUsPerCPU, 1))
+	if !nano.IsInt() {
+		return 0, fmt.Errorf("number of CPUs %q is too precise", value)
+	}
+	if !nano.Num().IsInt64() {
+		return 0, fmt.Errorf("number of CPUs %q is too large", value)
+	}
+	return NanoCPUs(nano.Num().Int64()), nil
+}
+
+// NanoCPUsFromFloat converts a number of CPUs, as set by service `cpus`, into NanoCPUs
+func NanoCPUsFromFloat(cpus float32) NanoCPUs {
+	// use shortest decimal representation, so that 0.1 is not converted as 0.100000001
+	n, _ := ParseNanoCPUs(strconv.FormatFloat(float64(cpus), 'f', -1, 32))
+	return n
+}
+
+// Value returns the number of CPUs
+func (n NanoCPUs) Value() float64 {
+	return float64(n) / nanoCPUsPerCPU
+}
+
+// String formats quantity as a decimal number of CPUs
+func (n NanoCPUs) String() string {
+	s := strconv.FormatInt(int64(n)/nanoCPUsPerCPU, 10)
+	if frac := int64(n) % nanoCPUsPerCPU; frac != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%09d", frac), "0")
+	}
+	return s
+}
+
+// MarshalYAML makes NanoCPUs implement yaml.Marshaller
+func (n NanoCPUs) MarshalYAML() (interface{}, error) {
+	return n.String(), nil
+}
+
+// MarshalJSON makes NanoCPUs implement json.Marshaler
+func (n NanoCPUs) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(n.String())), nil
+}
+
+func (n *NanoCPUs) DecodeMapstructure(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case int:
+		s = strconv.Itoa(v)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		s = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Errorf("invalid type %T for cpus", value)
+	}
+	cpus, err := ParseNanoCPUs(s)
+	if err != nil {
+		return err
+	}
+	*n = cpus
+	return nil
+}
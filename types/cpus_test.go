@@ -0,0 +1,62 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseNanoCPUs(t *testing.T) {
+	for value, expected := range map[string]NanoCPUs{
+		"2":      2_000_000_000,
+		"0.5":    500_000_000,
+		"0.0001": 100_000,
+		" 1.25 ": 1_250_000_000,
+	} {
+		n, err := ParseNanoCPUs(value)
+		assert.NilError(t, err)
+		assert.Equal(t, n, expected)
+	}
+	for value, expected := range map[string]string{
+		"abc":           `invalid number of CPUs "abc"`,
+		"-1":            `number of CPUs can't be negative: "-1"`,
+		"0.0000000001":  `number of CPUs "0.0000000001" is too precise`,
+		"1000000000000": `number of CPUs "1000000000000" is too large`,
+	} {
+		_, err := ParseNanoCPUs(value)
+		assert.Error(t, err, expected)
+	}
+}
+
+func TestNanoCPUsString(t *testing.T) {
+	assert.Equal(t, NanoCPUs(2_000_000_000).String(), "2")
+	assert.Equal(t, NanoCPUs(1_500_000_000).String(), "1.5")
+	assert.Equal(t, NanoCPUs(100_000).String(), "0.0001")
+	assert.Equal(t, NanoCPUsFromFloat(0.1), NanoCPUs(100_000_000))
+}
+
+func TestNanoCPUsDecode(t *testing.T) {
+	var n NanoCPUs
+	for _, value := range []any{"0.5", 0.5, float32(0.5)} {
+		assert.NilError(t, n.DecodeMapstructure(value))
+		assert.Equal(t, n, NanoCPUs(500_000_000))
+	}
+	assert.NilError(t, n.DecodeMapstructure(2))
+	assert.Equal(t, n, NanoCPUs(2_000_000_000))
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"github.com/compose-spec/compose-go/v2/tree"
+)
+
+// AddImplicitDefault records the attribute at path has been set by loader as an implicit default
+func (p *Project) AddImplicitDefault(path tree.Path) {
+	for _, implicit := range p.ImplicitDefaults {
+		if implicit == path {
+			return
+		}
+	}
+	p.ImplicitDefaults = append(p.ImplicitDefaults, path)
+}
+
+// IsImplicitDefault returns true if attribute at path has been set by loader as an implicit default
+func (p *Project) IsImplicitDefault(path tree.Path) bool {
+	for _, implicit := range p.ImplicitDefaults {
+		if implicit == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Denormalize removes the implicit defaults set by loader, so that project can be marshalled as
+// close as possible to the compose file(s) it was loaded from:
+// - the implicit `default` network and services' attachment to it
+// - `build.context` and `build.dockerfile` default values
+// - `depends_on` inferred from `links`, `volumes_from` and namespaces set as `service:name`
+func (p *Project) Denormalize() {
+	for _, path := range p.ImplicitDefaults {
+		parts := path.Parts()
+		switch {
+		case path.Matches("networks.default"):
+			delete(p.Networks, "default")
+		case path.Matches("services.*.networks.*"):
+			p.withService(parts[1], func(s *ServiceConfig) {
+				delete(s.Networks, parts[3])
+				if len(s.Networks) == 0 {
+					s.Networks = nil
+				}
+			})
+		case path.Matches("services.*.build.context"):
+			p.withService(parts[1], func(s *ServiceConfig) {
+				if s.Build != nil {
+					s.Build.Context = ""
+				}
+			})
+		case path.Matches("services.*.build.dockerfile"):
+			p.withService(parts[1], func(s *ServiceConfig) {
+				if s.Build != nil {
+					s.Build.Dockerfile = ""
+				}
+			})
+		case path.Matches("services.*.depends_on.*"):
+			p.withService(parts[1], func(s *ServiceConfig) {
+				delete(s.DependsOn, parts[3])
+				if len(s.DependsOn) == 0 {
+					s.DependsOn = nil
+				}
+			})
+		}
+	}
+	if len(p.Networks) == 0 {
+		p.Networks = nil
+	}
+	p.ImplicitDefaults = nil
+}
+
+// withService applies fn to service by name, if it exists
+func (p *Project) withService(name string, fn func(s *ServiceConfig)) {
+	s, ok := p.Services[name]
+	if !ok {
+		return
+	}
+	fn(&s)
+	p.Services[name] = s
+}
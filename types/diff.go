@@ -0,0 +1,88 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ProjectDiff describes the structural changes between two versions of a project
+type ProjectDiff struct {
+	Services ResourceDiff `yaml:"services,omitempty" json:"services,omitempty"`
+	Networks ResourceDiff `yaml:"networks,omitempty" json:"networks,omitempty"`
+	Volumes  ResourceDiff `yaml:"volumes,omitempty" json:"volumes,omitempty"`
+	Secrets  ResourceDiff `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+	Configs  ResourceDiff `yaml:"configs,omitempty" json:"configs,omitempty"`
+}
+
+// ResourceDiff lists names of resources added, removed or modified
+type ResourceDiff struct {
+	Added    []string `yaml:"added,omitempty" json:"added,omitempty"`
+	Removed  []string `yaml:"removed,omitempty" json:"removed,omitempty"`
+	Modified []string `yaml:"modified,omitempty" json:"modified,omitempty"`
+}
+
+// IsEmpty returns true if no resource has been added, removed or modified
+func (d ResourceDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// IsEmpty returns true if projects are structurally equivalent
+func (d ProjectDiff) IsEmpty() bool {
+	return d.Services.IsEmpty() && d.Networks.IsEmpty() && d.Volumes.IsEmpty() &&
+		d.Secrets.IsEmpty() && d.Configs.IsEmpty()
+}
+
+// Diff computes the structural changes required to turn project into other
+func (p *Project) Diff(other *Project) ProjectDiff {
+	if p == nil {
+		p = &Project{}
+	}
+	if other == nil {
+		other = &Project{}
+	}
+	return ProjectDiff{
+		Services: diffResources(p.Services, other.Services),
+		Networks: diffResources(p.Networks, other.Networks),
+		Volumes:  diffResources(p.Volumes, other.Volumes),
+		Secrets:  diffResources(p.Secrets, other.Secrets),
+		Configs:  diffResources(p.Configs, other.Configs),
+	}
+}
+
+func diffResources[T any](before, after map[string]T) ResourceDiff {
+	diff := ResourceDiff{}
+	for name, b := range before {
+		a, ok := after[name]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, name)
+		case !reflect.DeepEqual(a, b):
+			diff.Modified = append(diff.Modified, name)
+		}
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
@@ -0,0 +1,53 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProjectDiff(t *testing.T) {
+	before := &Project{
+		Services: Services{
+			"web": {Name: "web", Image: "nginx:1"},
+			"db":  {Name: "db", Image: "postgres"},
+		},
+		Volumes: Volumes{"data": {}},
+	}
+	after := &Project{
+		Services: Services{
+			"web":   {Name: "web", Image: "nginx:2"},
+			"cache": {Name: "cache", Image: "redis"},
+		},
+		Volumes: Volumes{"data": {}},
+	}
+	diff := before.Diff(after)
+	assert.DeepEqual(t, diff, ProjectDiff{
+		Services: ResourceDiff{
+			Added:    []string{"cache"},
+			Removed:  []string{"db"},
+			Modified: []string{"web"},
+		},
+	})
+	assert.Check(t, !diff.IsEmpty())
+	assert.Check(t, before.Diff(before).IsEmpty())
+
+	var empty *Project
+	assert.DeepEqual(t, empty.Diff(after).Services.Added, []string{"cache", "web"})
+}
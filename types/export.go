@@ -0,0 +1,346 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+	"github.com/pkg/errors"
+)
+
+// ExportedComposeFile is the name of the compose file written by Export at archive root
+const ExportedComposeFile = "compose.yaml"
+
+// ExportOptions control how a project is exported
+type ExportOptions struct {
+	// BuildContexts also exports local build contexts, honoring `.dockerignore`
+	BuildContexts bool
+}
+
+// WithBuildContexts sets ExportOptions to include local build contexts
+func WithBuildContexts(o *ExportOptions) {
+	o.BuildContexts = true
+}
+
+// Export writes a self-contained tar archive with the normalized compose file and
+// all local files it references: env_files, secret and config files, extends and
+// include targets, and optionally build contexts. All paths are rewritten relative
+// to the archive root, so that archive can be moved and loaded unchanged.
+//
+// Files within project's working directory keep their relative location, while
+// other files are stored under `_external/` with their absolute path. Bind mount
+// sources, and build contexts unless exported, are not bundled and must be within
+// project's working directory, otherwise Export returns an error.
+func (p *Project) Export(w io.Writer, options ...func(*ExportOptions)) error {
+	opts := ExportOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+	e := &exporter{
+		tw:         tar.NewWriter(w),
+		workingDir: p.WorkingDir,
+		written: map[string]bool{
+			// normalized compose file replaces any original one at archive root
+			ExportedComposeFile: true,
+		},
+	}
+	c, err := e.rewrite(p, opts)
+	if err != nil {
+		return err
+	}
+
+	// extends and include are resolved by loader, so their targets are bundled as recorded while loading
+	for _, f := range p.InputFiles {
+		switch f.Kind {
+		case InputFileInclude, InputFileExtends, InputFileDotEnv:
+			if _, err := e.add(f.Path, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	b, err := c.MarshalYAML()
+	if err != nil {
+		return err
+	}
+	err = e.tw.WriteHeader(&tar.Header{
+		Name:     ExportedComposeFile,
+		Typeflag: tar.TypeReg,
+		Mode:     0o644,
+		Size:     int64(len(b)),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := e.tw.Write(b); err != nil {
+		return err
+	}
+	return e.tw.Close()
+}
+
+type exporter struct {
+	tw         *tar.Writer
+	workingDir string
+	written    map[string]bool
+}
+
+// rewrite creates a copy of project with local files added to the archive and paths made relative
+func (e *exporter) rewrite(p *Project, opts ExportOptions) (*Project, error) {
+	c := *p
+	c.Services = Services{}
+	for name, s := range p.Services {
+		var err error
+		if len(s.EnvFile) > 0 {
+			envFiles := make(StringList, len(s.EnvFile))
+			for i, f := range s.EnvFile {
+				envFiles[i], err = e.add(f, nil)
+				if err != nil {
+					return nil, err
+				}
+			}
+			s.EnvFile = envFiles
+		}
+
+		if s.Build != nil {
+			build := *s.Build
+			build.Context, err = e.context(fmt.Sprintf("services.%s.build.context", name), build.Context, opts)
+			if err != nil {
+				return nil, err
+			}
+			if len(build.AdditionalContexts) > 0 {
+				contexts := Mapping{}
+				for k, v := range build.AdditionalContexts {
+					contexts[k], err = e.context(fmt.Sprintf("services.%s.build.additional_contexts.%s", name, k), v, opts)
+					if err != nil {
+						return nil, err
+					}
+				}
+				build.AdditionalContexts = contexts
+			}
+			s.Build = &build
+		}
+
+		if len(s.Volumes) > 0 {
+			volumes := make([]ServiceVolumeConfig, len(s.Volumes))
+			for i, v := range s.Volumes {
+				if v.Type == VolumeTypeBind {
+					v.Source, err = e.local(fmt.Sprintf("services.%s.volumes[%d].source", name, i), v.Source)
+					if err != nil {
+						return nil, err
+					}
+				}
+				volumes[i] = v
+			}
+			s.Volumes = volumes
+		}
+		c.Services[name] = s
+	}
+
+	if p.Secrets != nil {
+		c.Secrets = Secrets{}
+		for name, s := range p.Secrets {
+			if s.File != "" {
+				f, err := e.add(s.File, nil)
+				if err != nil {
+					return nil, err
+				}
+				s.File = f
+			}
+			c.Secrets[name] = s
+		}
+	}
+
+	if p.Configs != nil {
+		c.Configs = Configs{}
+		for name, s := range p.Configs {
+			if s.File != "" {
+				f, err := e.add(s.File, nil)
+				if err != nil {
+					return nil, err
+				}
+				s.File = f
+			}
+			c.Configs[name] = s
+		}
+	}
+	return &c, nil
+}
+
+// context exports a local build context if requested, and returns its relative path
+func (e *exporter) context(attr string, dir string, opts ExportOptions) (string, error) {
+	if !filepath.IsAbs(dir) {
+		// remote context or builder specific reference
+		return dir, nil
+	}
+	if !opts.BuildContexts {
+		return e.local(attr, dir)
+	}
+	ignore, err := readDockerIgnore(dir)
+	if err != nil {
+		return "", err
+	}
+	return e.add(dir, ignore)
+}
+
+// relative returns path relative to archive root for a file within working directory,
+// or the unchanged path otherwise
+func (e *exporter) relative(p string) string {
+	if !filepath.IsAbs(p) {
+		return p
+	}
+	rel, err := filepath.Rel(e.workingDir, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	if rel == "." {
+		return rel
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+// local returns path relative to archive root for a file which is not bundled, set by attribute attr. It must be
+// within working directory, so that archive is self-contained.
+func (e *exporter) local(attr string, p string) (string, error) {
+	rel := e.relative(p)
+	if filepath.IsAbs(rel) {
+		return "", errors.Errorf("%s %s is outside project working directory and can't be exported", attr, p)
+	}
+	return rel, nil
+}
+
+// archivePath computes the location of a local file inside archive
+func (e *exporter) archivePath(p string) string {
+	rel := e.relative(p)
+	if rel != p {
+		return path.Clean(rel)
+	}
+	abs := strings.TrimPrefix(p, filepath.VolumeName(p))
+	return path.Join("_external", filepath.ToSlash(abs))
+}
+
+// add copies a local file or directory into archive and returns its path relative to archive root
+func (e *exporter) add(p string, ignore *patternmatcher.PatternMatcher) (string, error) {
+	name := e.archivePath(p)
+	// a symlink set as file or context is followed, and its target exported with the declared name
+	root, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to export %s", p)
+	}
+	err = filepath.Walk(root, func(f string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, f)
+		if err != nil {
+			return err
+		}
+		if ignore != nil && rel != "." {
+			excluded, err := ignore.MatchesOrParentMatches(filepath.ToSlash(rel))
+			if err != nil {
+				return err
+			}
+			if excluded {
+				if info.IsDir() && !ignore.Exclusions() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(f); err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return errors.Errorf("%s is a symlink to a directory, which is not supported", f)
+			}
+		}
+		target := path.Join(name, filepath.ToSlash(rel))
+		if e.written[target] {
+			return nil
+		}
+		e.written[target] = true
+		return e.write(f, target, info)
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to export %s", p)
+	}
+	if name == "." {
+		return ".", nil
+	}
+	return "./" + name, nil
+}
+
+func (e *exporter) write(f string, name string, info os.FileInfo) error {
+	switch {
+	case info.IsDir():
+		if name == "." {
+			return nil
+		}
+		return e.tw.WriteHeader(&tar.Header{
+			Name:     name + "/",
+			Typeflag: tar.TypeDir,
+			Mode:     int64(info.Mode().Perm()),
+			ModTime:  info.ModTime(),
+		})
+	case info.Mode().IsRegular():
+		err := e.tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     int64(info.Mode().Perm()),
+			Size:     info.Size(),
+			ModTime:  info.ModTime(),
+		})
+		if err != nil {
+			return err
+		}
+		r, err := os.Open(f)
+		if err != nil {
+			return err
+		}
+		defer r.Close() //nolint:errcheck
+		_, err = io.Copy(e.tw, r)
+		return err
+	default:
+		return errors.Errorf("%s is not a regular file", f)
+	}
+}
+
+// readDockerIgnore reads the exclusion patterns set by the .dockerignore file in dir, if any
+func readDockerIgnore(dir string) (*patternmatcher.PatternMatcher, error) {
+	f, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	patterns, err := ignorefile.ReadAll(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid .dockerignore in %s", dir)
+	}
+	return patternmatcher.New(patterns)
+}
@@ -0,0 +1,205 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+)
+
+func readTar(t *testing.T, b []byte) map[string]string {
+	files := map[string]string{}
+	tr := tar.NewReader(bytes.NewReader(b))
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		assert.NilError(t, err)
+		content, err := io.ReadAll(tr)
+		assert.NilError(t, err)
+		files[h.Name] = string(content)
+	}
+}
+
+func TestExport(t *testing.T) {
+	wd := t.TempDir()
+	external := t.TempDir()
+	writeFile := func(p, content string) {
+		assert.NilError(t, os.MkdirAll(filepath.Dir(p), 0o700))
+		assert.NilError(t, os.WriteFile(p, []byte(content), 0o600))
+	}
+	writeFile(filepath.Join(wd, "web.env"), "FOO=BAR")
+	writeFile(filepath.Join(wd, "compose.yaml"), "original")
+	writeFile(filepath.Join(wd, "app", "Dockerfile"), "FROM scratch")
+	writeFile(filepath.Join(wd, "app", "main.go"), "package main")
+	writeFile(filepath.Join(wd, "app", ".dockerignore"), "*.log\nnode_modules\n!keep.log")
+	writeFile(filepath.Join(wd, "app", "debug.log"), "noise")
+	writeFile(filepath.Join(wd, "app", "keep.log"), "keep")
+	writeFile(filepath.Join(wd, "app", "node_modules", "x", "index.js"), "noise")
+	writeFile(filepath.Join(external, "key.pem"), "secret")
+
+	p := &Project{
+		Name:       "test",
+		WorkingDir: wd,
+		Services: Services{
+			"web": {
+				Name:    "web",
+				EnvFile: StringList{filepath.Join(wd, "web.env")},
+				Build: &BuildConfig{
+					Context:    filepath.Join(wd, "app"),
+					Dockerfile: "Dockerfile",
+				},
+				Volumes: []ServiceVolumeConfig{
+					{Type: VolumeTypeBind, Source: filepath.Join(wd, "data"), Target: "/data"},
+				},
+			},
+		},
+		Secrets: Secrets{
+			"key": {File: filepath.Join(external, "key.pem")},
+		},
+	}
+
+	buf := bytes.Buffer{}
+	err := p.Export(&buf, WithBuildContexts)
+	assert.NilError(t, err)
+
+	files := readTar(t, buf.Bytes())
+	assert.Equal(t, files["web.env"], "FOO=BAR")
+	assert.Equal(t, files["app/Dockerfile"], "FROM scratch")
+	assert.Equal(t, files["app/keep.log"], "keep")
+	_, ok := files["app/debug.log"]
+	assert.Check(t, !ok)
+	_, ok = files["app/node_modules/x/index.js"]
+	assert.Check(t, !ok)
+	assert.Equal(t, files[filepath.ToSlash(filepath.Join("_external", external, "key.pem"))], "secret")
+
+	var exported Project
+	err = yaml.Unmarshal([]byte(files[ExportedComposeFile]), &exported)
+	assert.NilError(t, err)
+	web := exported.Services["web"]
+	assert.DeepEqual(t, web.EnvFile, StringList{"./web.env"})
+	assert.Equal(t, web.Build.Context, "./app")
+	assert.Equal(t, web.Volumes[0].Source, "./data")
+	assert.Equal(t, exported.Secrets["key"].File, "./"+filepath.ToSlash(filepath.Join("_external", external, "key.pem")))
+
+	// original project is left unchanged
+	assert.Equal(t, p.Services["web"].Build.Context, filepath.Join(wd, "app"))
+	assert.Equal(t, p.Secrets["key"].File, filepath.Join(external, "key.pem"))
+}
+
+func TestExportWithoutBuildContexts(t *testing.T) {
+	wd := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(wd, "main.go"), []byte("package main"), 0o600))
+	p := &Project{
+		Name:       "test",
+		WorkingDir: wd,
+		Services: Services{
+			"web": {
+				Name:  "web",
+				Build: &BuildConfig{Context: wd},
+			},
+		},
+	}
+	buf := bytes.Buffer{}
+	err := p.Export(&buf)
+	assert.NilError(t, err)
+	files := readTar(t, buf.Bytes())
+	assert.Equal(t, len(files), 1)
+	_, ok := files[ExportedComposeFile]
+	assert.Check(t, ok)
+}
+
+func TestExportOutsideWorkingDir(t *testing.T) {
+	wd := t.TempDir()
+	external := t.TempDir()
+	p := &Project{
+		Name:       "test",
+		WorkingDir: wd,
+		Services: Services{
+			"web": {
+				Name: "web",
+				Volumes: []ServiceVolumeConfig{
+					{Type: VolumeTypeBind, Source: external, Target: "/data"},
+				},
+			},
+		},
+	}
+	err := p.Export(io.Discard)
+	assert.Error(t, err, "services.web.volumes[0].source "+external+" is outside project working directory and can't be exported")
+
+	p.Services["web"] = ServiceConfig{
+		Name:  "web",
+		Build: &BuildConfig{Context: external},
+	}
+	err = p.Export(io.Discard)
+	assert.Error(t, err, "services.web.build.context "+external+" is outside project working directory and can't be exported")
+}
+
+func TestExportInputFiles(t *testing.T) {
+	wd := t.TempDir()
+	writeFile := func(p, content string) {
+		assert.NilError(t, os.MkdirAll(filepath.Dir(p), 0o700))
+		assert.NilError(t, os.WriteFile(p, []byte(content), 0o600))
+	}
+	writeFile(filepath.Join(wd, "base.yaml"), "services: {base: {image: nginx}}")
+	writeFile(filepath.Join(wd, "db", "compose.yaml"), "services: {db: {image: postgres}}")
+	writeFile(filepath.Join(wd, "db", ".env"), "TAG=16")
+	p := &Project{
+		Name:       "test",
+		WorkingDir: wd,
+		Services: Services{
+			"web": {Name: "web", Image: "nginx"},
+		},
+		InputFiles: []InputFile{
+			NewInputFile(InputFileExtends, filepath.Join(wd, "base.yaml"), nil),
+			NewInputFile(InputFileInclude, filepath.Join(wd, "db", "compose.yaml"), nil),
+			NewInputFile(InputFileDotEnv, filepath.Join(wd, "db", ".env"), nil),
+		},
+	}
+	buf := bytes.Buffer{}
+	assert.NilError(t, p.Export(&buf))
+	files := readTar(t, buf.Bytes())
+	assert.Equal(t, files["base.yaml"], "services: {base: {image: nginx}}")
+	assert.Equal(t, files["db/compose.yaml"], "services: {db: {image: postgres}}")
+	assert.Equal(t, files["db/.env"], "TAG=16")
+}
+
+func TestExportSymlink(t *testing.T) {
+	wd := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(wd, "key.pem"), []byte("secret"), 0o600))
+	assert.NilError(t, os.Symlink("key.pem", filepath.Join(wd, "link.pem")))
+	p := &Project{
+		Name:       "test",
+		WorkingDir: wd,
+		Services:   Services{},
+		Secrets: Secrets{
+			"key": {File: filepath.Join(wd, "link.pem")},
+		},
+	}
+	buf := bytes.Buffer{}
+	assert.NilError(t, p.Export(&buf))
+	files := readTar(t, buf.Bytes())
+	assert.Equal(t, files["link.pem"], "secret")
+}
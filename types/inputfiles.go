@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	_ "crypto/sha256" // register sha256 as digest.Canonical algorithm
+	"os"
+	"sort"
+
+	godigest "github.com/opencontainers/go-digest"
+)
+
+// InputFileKind identifies the role of a file used to load a project
+type InputFileKind string
+
+const (
+	// InputFileCompose is a compose file set by ConfigDetails
+	InputFileCompose InputFileKind = "compose"
+	// InputFileInclude is a compose file loaded by `include`
+	InputFileInclude InputFileKind = "include"
+	// InputFileExtends is a compose file loaded by `extends.file`
+	InputFileExtends InputFileKind = "extends"
+	// InputFileDotEnv is a `.env` file used for interpolation
+	InputFileDotEnv InputFileKind = "dotenv"
+	// InputFileEnvFile is a service `env_file`
+	InputFileEnvFile InputFileKind = "env_file"
+	// InputFileSecret is a secret `file` source
+	InputFileSecret InputFileKind = "secret"
+	// InputFileConfig is a config `file` source
+	InputFileConfig InputFileKind = "config"
+)
+
+// InputFile is a local file which has been read to produce a Project
+type InputFile struct {
+	Path   string          `yaml:"path" json:"path"`
+	Kind   InputFileKind   `yaml:"kind" json:"kind"`
+	Digest godigest.Digest `yaml:"digest,omitempty" json:"digest,omitempty"`
+}
+
+// NewInputFile creates an InputFile for content read from path
+func NewInputFile(kind InputFileKind, path string, content []byte) InputFile {
+	return InputFile{
+		Path:   path,
+		Kind:   kind,
+		Digest: godigest.FromBytes(content),
+	}
+}
+
+// ReadInputFile reads file at path to create an InputFile
+func ReadInputFile(kind InputFileKind, path string) (InputFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return InputFile{}, err
+	}
+	return NewInputFile(kind, path, content), nil
+}
+
+// InputFilesDigest computes a digest for the whole set of input files, suitable
+// to be used as a cache key
+func (p *Project) InputFilesDigest() godigest.Digest {
+	var lines []string
+	for _, f := range p.InputFiles {
+		lines = append(lines, string(f.Kind)+" "+f.Path+" "+f.Digest.String())
+	}
+	sort.Strings(lines)
+	digester := godigest.Canonical.Digester()
+	for _, l := range lines {
+		_, _ = digester.Hash().Write([]byte(l + "\n"))
+	}
+	return digester.Digest()
+}
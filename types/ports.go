@@ -0,0 +1,136 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// PublishedPort is a range of host ports allocated by a service port
+type PublishedPort struct {
+	// Service is the name of the service publishing port
+	Service string
+	// Index is the position of the port in service's ports
+	Index int
+	// HostIP is the host address port is bound to, empty for all interfaces
+	HostIP string
+	// Protocol is either tcp, udp or sctp
+	Protocol string
+	// Mode is either ingress or host
+	Mode string
+	// Start is the first host port of the published range
+	Start uint32
+	// End is the last host port of the published range, equal to Start for a single port
+	End uint32
+	// Target is the container port
+	Target uint32
+	// Replicas is the number of containers running the service
+	Replicas int
+	// NodeReplicas is the number of containers allocating a host port within range on a single node. Ingress ports
+	// are allocated once by the routing mesh for all replicas, host ports by every replica scheduled on the node
+	NodeReplicas int
+}
+
+func (p PublishedPort) String() string {
+	host := p.HostIP
+	if host == "" {
+		host = "0.0.0.0"
+	}
+	port := fmt.Sprint(p.Start)
+	if p.End != p.Start {
+		port = fmt.Sprintf("%d-%d", p.Start, p.End)
+	}
+	return fmt.Sprintf("%s/%s", net.JoinHostPort(host, port), p.Protocol)
+}
+
+// Overlaps returns true if ports p and o can't be allocated together, as they share protocol, host address and
+// port numbers. Ingress ports are exclusive, as the routing mesh binds them on all interfaces of every node
+func (p PublishedPort) Overlaps(o PublishedPort) bool {
+	if p.Protocol != o.Protocol {
+		return false
+	}
+	exclusive := p.Mode == "ingress" || o.Mode == "ingress"
+	if !exclusive && !isUnspecifiedIP(p.HostIP) && !isUnspecifiedIP(o.HostIP) && !net.ParseIP(p.HostIP).Equal(net.ParseIP(o.HostIP)) {
+		return false
+	}
+	return p.Start <= o.End && o.Start <= p.End
+}
+
+// isUnspecifiedIP returns true if ip is bound to all interfaces
+func isUnspecifiedIP(ip string) bool {
+	return ip == "" || net.ParseIP(ip).IsUnspecified()
+}
+
+// PublishedPorts returns the host ports allocated by services, sorted by host port. Ports without a published
+// host port, which get an ephemeral port allocated, are not included.
+func (p *Project) PublishedPorts() ([]PublishedPort, error) {
+	var ports []PublishedPort
+	for name, service := range p.Services {
+		for i, port := range service.Ports {
+			if port.Published == "" {
+				continue
+			}
+			start, end, err := nat.ParsePortRangeToInt(port.Published)
+			if err != nil {
+				return nil, fmt.Errorf("services.%s.ports[%d]: %w", name, i, err)
+			}
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			mode := port.Mode
+			if mode == "" {
+				mode = "ingress"
+			}
+			replicas := service.GetScale()
+			nodeReplicas := 1
+			if mode == "host" {
+				nodeReplicas = replicas
+				if service.Deploy != nil && service.Deploy.Placement.MaxReplicas > 0 &&
+					service.Deploy.Placement.MaxReplicas < uint64(replicas) {
+					nodeReplicas = int(service.Deploy.Placement.MaxReplicas)
+				}
+			}
+			ports = append(ports, PublishedPort{
+				Service:      name,
+				Index:        i,
+				HostIP:       port.HostIP,
+				Protocol:     protocol,
+				Mode:         mode,
+				Start:        uint32(start),
+				End:          uint32(end),
+				Target:       port.Target,
+				Replicas:     replicas,
+				NodeReplicas: nodeReplicas,
+			})
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Start != ports[j].Start {
+			return ports[i].Start < ports[j].Start
+		}
+		if ports[i].Service != ports[j].Service {
+			return ports[i].Service < ports[j].Service
+		}
+		return ports[i].Index < ports[j].Index
+	})
+	return ports, nil
+}
@@ -25,6 +25,7 @@ import (
 	"sort"
 
 	"github.com/compose-spec/compose-go/v2/dotenv"
+	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/compose-spec/compose-go/v2/utils"
 	"github.com/distribution/reference"
 	godigest "github.com/opencontainers/go-digest"
@@ -47,11 +48,18 @@ type Project struct {
 	// IncludeReferences is keyed by Compose YAML filename and contains config for
 	// other Compose YAML files it directly triggered a load of via `include`.
 	//
-	// Note: this is
+	// Note: this is only populated by the loader, and is not serialized.
 	IncludeReferences map[string][]IncludeConfig `yaml:"-" json:"-"`
 	ComposeFiles      []string                   `yaml:"-" json:"-"`
 	Environment       Mapping                    `yaml:"-" json:"-"`
 
+	// InputFiles lists all local files read to load this project, with their content digest. Digest is empty for a
+	// file which could not be read
+	InputFiles []InputFile `yaml:"-" json:"-"`
+
+	// ImplicitDefaults lists the attributes set by loader as implicit defaults, by their path in compose model
+	ImplicitDefaults []tree.Path `yaml:"-" json:"-"`
+
 	// DisabledServices track services which have been disable as profile is not active
 	DisabledServices Services `yaml:"-" json:"-"`
 	Profiles         []string `yaml:"-" json:"-"`
@@ -480,19 +488,124 @@ func (p *Project) ResolveImages(resolver func(named reference.Named) (godigest.D
 	return eg.Wait()
 }
 
+// MarshalOptions configure how a Project is marshalled
+type MarshalOptions struct {
+	// Transform is applied to the yaml tree before it is marshalled
+	Transform func(map[string]any) (map[string]any, error)
+}
+
+// WithTransform sets a transformation, like transform.Compact, to be applied to the yaml tree before it is marshalled
+func WithTransform(fn func(map[string]any) (map[string]any, error)) func(*MarshalOptions) {
+	return func(o *MarshalOptions) {
+		o.Transform = fn
+	}
+}
+
 // MarshalYAML marshal Project into a yaml tree
-func (p *Project) MarshalYAML() ([]byte, error) {
+func (p *Project) MarshalYAML(options ...func(*MarshalOptions)) ([]byte, error) {
+	opts := MarshalOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var value any = p
+	if opts.Transform != nil {
+		var node yaml.Node
+		if err := node.Encode(p); err != nil {
+			return nil, err
+		}
+		var model map[string]any
+		if err := node.Decode(&model); err != nil {
+			return nil, err
+		}
+		model, err := opts.Transform(model)
+		if err != nil {
+			return nil, err
+		}
+		// keep attributes ordered as declared by go structs
+		value, err = orderedNode(model, &node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	buf := bytes.NewBuffer([]byte{})
 	encoder := yaml.NewEncoder(buf)
 	encoder.SetIndent(2)
 	// encoder.CompactSeqIndent() FIXME https://github.com/go-yaml/yaml/pull/753
-	err := encoder.Encode(p)
+	err := encoder.Encode(value)
 	if err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
 }
 
+// orderedNode encodes value as a yaml node, with mapping keys ordered as in reference node.
+// Keys not declared by reference are sorted.
+func orderedNode(value any, reference *yaml.Node) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		if reference == nil || reference.Kind != yaml.MappingNode {
+			reference = &yaml.Node{}
+		}
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		add := func(key string, ref *yaml.Node) error {
+			n, err := orderedNode(v[key], ref)
+			if err != nil {
+				return err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, n)
+			return nil
+		}
+		seen := map[string]bool{}
+		for i := 0; i+1 < len(reference.Content); i += 2 {
+			key := reference.Content[i].Value
+			if _, ok := v[key]; ok {
+				seen[key] = true
+				if err := add(key, reference.Content[i+1]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		var others []string
+		for key := range v {
+			if !seen[key] {
+				others = append(others, key)
+			}
+		}
+		sort.Strings(others)
+		for _, key := range others {
+			if err := add(key, nil); err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i, e := range v {
+			var ref *yaml.Node
+			if reference != nil && reference.Kind == yaml.SequenceNode && i < len(reference.Content) {
+				ref = reference.Content[i]
+			}
+			n, err := orderedNode(e, ref)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, n)
+		}
+		return node, nil
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && node.Style == 0 && utils.IsBase60(node.Value) {
+		// YAML 1.1 parsers would read a value like "20:30" as a sexagesimal number
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	return node, nil
+}
+
 // MarshalJSON makes Config implement json.Marshaler
 func (p *Project) MarshalJSON() ([]byte, error) {
 	m := map[string]interface{}{
@@ -238,3 +238,38 @@ func TestWithServices(t *testing.T) {
 	assert.NilError(t, err)
 	assert.DeepEqual(t, seen, []string{"service_1", "service_2", "service_4"})
 }
+
+func TestPublishedPorts(t *testing.T) {
+	scale := 3
+	p := Project{
+		Services: Services{
+			"web": {
+				Name: "web",
+				Ports: []ServicePortConfig{
+					{Target: 80, Published: "8080", Protocol: "tcp", Mode: "ingress"},
+					{Target: 443},
+				},
+			},
+			"api": {
+				Name:  "api",
+				Scale: &scale,
+				Ports: []ServicePortConfig{
+					{Target: 8000, Published: "7000-7002", HostIP: "127.0.0.1", Mode: "host"},
+				},
+			},
+		},
+	}
+	ports, err := p.PublishedPorts()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ports, []PublishedPort{
+		{Service: "api", Index: 0, HostIP: "127.0.0.1", Protocol: "tcp", Mode: "host", Start: 7000, End: 7002, Target: 8000, Replicas: 3, NodeReplicas: 3},
+		{Service: "web", Index: 0, Protocol: "tcp", Mode: "ingress", Start: 8080, End: 8080, Target: 80, Replicas: 1, NodeReplicas: 1},
+	})
+	assert.Equal(t, ports[0].String(), "127.0.0.1:7000-7002/tcp")
+	assert.Check(t, !ports[0].Overlaps(ports[1]))
+	assert.Check(t, !ports[0].Overlaps(PublishedPort{HostIP: "10.0.0.1", Protocol: "tcp", Mode: "host", Start: 7000, End: 7000}))
+	// ingress ports are bound to all interfaces, whatever host_ip is set
+	assert.Check(t, ports[0].Overlaps(PublishedPort{HostIP: "10.0.0.1", Protocol: "tcp", Mode: "ingress", Start: 7000, End: 7000}))
+	assert.Check(t, ports[1].Overlaps(PublishedPort{HostIP: "10.0.0.1", Protocol: "tcp", Mode: "host", Start: 8000, End: 9000}))
+	assert.Check(t, !ports[1].Overlaps(PublishedPort{Protocol: "udp", Start: 8080, End: 8080}))
+}
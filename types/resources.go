@@ -0,0 +1,188 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// EffectiveResources returns service's resource limits and reservations, reconciling service-level `cpus`,
+// `mem_limit`, `mem_reservation` and `pids_limit` with `deploy.resources`, which takes precedence when both are set.
+// Limits and Reservations are nil if service doesn't declare any.
+func (s *ServiceConfig) EffectiveResources() Resources {
+	var resources Resources
+	if s.Deploy != nil {
+		if s.Deploy.Resources.Limits != nil {
+			limits := *s.Deploy.Resources.Limits
+			resources.Limits = &limits
+		}
+		if s.Deploy.Resources.Reservations != nil {
+			reservations := *s.Deploy.Resources.Reservations
+			resources.Reservations = &reservations
+		}
+	}
+
+	limits := resources.Limits
+	if limits == nil {
+		limits = &Resource{}
+	}
+	if limits.NanoCPUs == 0 && s.CPUS != 0 {
+		limits.NanoCPUs = NanoCPUsFromFloat(s.CPUS)
+	}
+	if limits.MemoryBytes == 0 {
+		limits.MemoryBytes = s.MemLimit
+	}
+	if limits.Pids == 0 {
+		limits.Pids = s.PidsLimit
+	}
+	if resources.Limits == nil && (limits.NanoCPUs != 0 || limits.MemoryBytes != 0 || limits.Pids != 0) {
+		resources.Limits = limits
+	}
+
+	if s.MemReservation != 0 {
+		if resources.Reservations == nil {
+			resources.Reservations = &Resource{}
+		}
+		if resources.Reservations.MemoryBytes == 0 {
+			resources.Reservations.MemoryBytes = s.MemReservation
+		}
+	}
+	return resources
+}
+
+// ResourceTotals is the sum of resources requested by a set of services
+type ResourceTotals struct {
+	NanoCPUs    NanoCPUs  `yaml:"cpus,omitempty" json:"cpus,omitempty"`
+	MemoryBytes UnitBytes `yaml:"memory,omitempty" json:"memory,omitempty"`
+	Pids        int64     `yaml:"pids,omitempty" json:"pids,omitempty"`
+	// Devices counts requested devices by capabilities, or driver if capabilities are not set. -1 means all
+	// available devices are requested
+	Devices map[string]DeviceCount `yaml:"devices,omitempty" json:"devices,omitempty"`
+	// GenericResources counts requested generic resources by kind
+	GenericResources map[string]int64 `yaml:"generic_resources,omitempty" json:"generic_resources,omitempty"`
+}
+
+// ResourceSummary is the resource footprint of a set of services, accounting for their replicas
+type ResourceSummary struct {
+	// Services is the number of services
+	Services int `yaml:"services" json:"services"`
+	// Containers is the number of containers running services, per node for services deployed in global mode
+	Containers int `yaml:"containers" json:"containers"`
+	// Limits is the sum of services limits
+	Limits ResourceTotals `yaml:"limits" json:"limits"`
+	// Reservations is the sum of services reservations
+	Reservations ResourceTotals `yaml:"reservations" json:"reservations"`
+	// Global lists services deployed in global mode, which run a container on every node
+	Global []string `yaml:"global,omitempty" json:"global,omitempty"`
+	// Unlimited lists services which don't declare any limit, and as such can use all host resources
+	Unlimited []string `yaml:"unlimited,omitempty" json:"unlimited,omitempty"`
+}
+
+// ResourceSummary returns the resource footprint of project's enabled services
+func (p *Project) ResourceSummary() ResourceSummary {
+	return summarize(p.Services)
+}
+
+// ResourceSummaryByProfile returns the resource footprint of project's services enabled by each profile, services
+// without profiles being included in all of them. Summary for services enabled without any profile is keyed by "".
+func (p *Project) ResourceSummaryByProfile() map[string]ResourceSummary {
+	all := p.AllServices()
+	profiles := map[string]Services{"": {}}
+	for _, service := range all {
+		for _, profile := range service.Profiles {
+			profiles[profile] = Services{}
+		}
+	}
+	for profile, services := range profiles {
+		for name, service := range all {
+			if service.HasProfile([]string{profile}) {
+				services[name] = service
+			}
+		}
+	}
+	summaries := make(map[string]ResourceSummary, len(profiles))
+	for profile, services := range profiles {
+		summaries[profile] = summarize(services)
+	}
+	return summaries
+}
+
+func summarize(services Services) ResourceSummary {
+	summary := ResourceSummary{
+		Services: len(services),
+	}
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		service := services[name]
+		replicas := service.GetScale()
+		if service.Deploy != nil && service.Deploy.Mode == "global" {
+			replicas = 1
+			summary.Global = append(summary.Global, name)
+		}
+		summary.Containers += replicas
+
+		resources := service.EffectiveResources()
+		if resources.Limits == nil {
+			summary.Unlimited = append(summary.Unlimited, name)
+		}
+		summary.Limits.add(resources.Limits, replicas)
+		summary.Reservations.add(resources.Reservations, replicas)
+	}
+	return summary
+}
+
+func (t *ResourceTotals) add(r *Resource, replicas int) {
+	if r == nil {
+		return
+	}
+	t.NanoCPUs += r.NanoCPUs * NanoCPUs(replicas)
+	t.MemoryBytes += r.MemoryBytes * UnitBytes(replicas)
+	t.Pids += r.Pids * int64(replicas)
+	for _, device := range r.Devices {
+		if t.Devices == nil {
+			t.Devices = map[string]DeviceCount{}
+		}
+		kind := strings.Join(device.Capabilities, ",")
+		if kind == "" {
+			kind = device.Driver
+		}
+		count := device.Count
+		if count == 0 {
+			count = DeviceCount(len(device.IDs))
+		}
+		switch {
+		case count < 0 || t.Devices[kind] < 0:
+			t.Devices[kind] = -1
+		default:
+			t.Devices[kind] += count * DeviceCount(replicas)
+		}
+	}
+	for _, generic := range r.GenericResources {
+		if generic.DiscreteResourceSpec == nil {
+			continue
+		}
+		if t.GenericResources == nil {
+			t.GenericResources = map[string]int64{}
+		}
+		t.GenericResources[generic.DiscreteResourceSpec.Kind] += generic.DiscreteResourceSpec.Value * int64(replicas)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestEffectiveResources(t *testing.T) {
+	s := ServiceConfig{
+		CPUS:           0.5,
+		MemLimit:       1024,
+		MemReservation: 512,
+		Deploy: &DeployConfig{
+			Resources: Resources{
+				Limits: &Resource{Pids: 100, MemoryBytes: 2048},
+			},
+		},
+	}
+	assert.DeepEqual(t, s.EffectiveResources(), Resources{
+		Limits:       &Resource{NanoCPUs: 500_000_000, MemoryBytes: 2048, Pids: 100},
+		Reservations: &Resource{MemoryBytes: 512},
+	})
+	// service definition is not modified
+	assert.Equal(t, s.Deploy.Resources.Limits.NanoCPUs, NanoCPUs(0))
+
+	assert.DeepEqual(t, (&ServiceConfig{}).EffectiveResources(), Resources{})
+}
+
+func TestResourceSummary(t *testing.T) {
+	three := 3
+	p := Project{
+		Services: Services{
+			"web": {
+				Name:  "web",
+				Scale: &three,
+				Deploy: &DeployConfig{Resources: Resources{
+					Limits:       &Resource{NanoCPUs: 500_000_000, MemoryBytes: 256},
+					Reservations: &Resource{MemoryBytes: 128},
+				}},
+			},
+			"agent": {
+				Name: "agent",
+				Deploy: &DeployConfig{
+					Mode: "global",
+					Resources: Resources{
+						Limits: &Resource{Pids: 100},
+					},
+				},
+			},
+			"ml": {
+				Name:     "ml",
+				MemLimit: 1024,
+				Deploy: &DeployConfig{Resources: Resources{
+					Reservations: &Resource{
+						Devices: []DeviceRequest{{Capabilities: []string{"gpu"}, Count: 2}},
+						GenericResources: []GenericResource{
+							{DiscreteResourceSpec: &DiscreteGenericResource{Kind: "SSD", Value: 1}},
+						},
+					},
+				}},
+			},
+			"db": {
+				Name: "db",
+			},
+		},
+		DisabledServices: Services{
+			"debug": {
+				Name:     "debug",
+				Profiles: []string{"debug"},
+				Deploy: &DeployConfig{Resources: Resources{
+					Reservations: &Resource{
+						Devices: []DeviceRequest{{Capabilities: []string{"gpu"}, Count: -1}},
+					},
+				}},
+			},
+		},
+	}
+	summary := ResourceSummary{
+		Services:   4,
+		Containers: 6,
+		Limits: ResourceTotals{
+			NanoCPUs:    1_500_000_000,
+			MemoryBytes: 3*256 + 1024,
+			Pids:        100,
+		},
+		Reservations: ResourceTotals{
+			MemoryBytes:      3 * 128,
+			Devices:          map[string]DeviceCount{"gpu": 2},
+			GenericResources: map[string]int64{"SSD": 1},
+		},
+		Global:    []string{"agent"},
+		Unlimited: []string{"db"},
+	}
+	assert.DeepEqual(t, p.ResourceSummary(), summary)
+
+	byProfile := p.ResourceSummaryByProfile()
+	assert.DeepEqual(t, byProfile[""], summary)
+	debug := byProfile["debug"]
+	assert.Equal(t, debug.Services, 5)
+	assert.DeepEqual(t, debug.Reservations.Devices, map[string]DeviceCount{"gpu": -1})
+	assert.DeepEqual(t, debug.Unlimited, []string{"db", "debug"})
+}
@@ -132,6 +132,9 @@ type ServiceConfig struct {
 	WorkingDir      string                           `yaml:"working_dir,omitempty" json:"working_dir,omitempty"`
 
 	Extensions Extensions `yaml:"#extensions,inline" json:"-"`
+
+	// Origin is the path of the compose file which declared this service, including files imported by `include`
+	Origin string `yaml:"-" json:"-"`
 }
 
 // MarshalYAML makes ServiceConfig implement yaml.Marshaller
@@ -366,8 +369,9 @@ type Resources struct {
 
 // Resource is a resource to be limited or reserved
 type Resource struct {
-	// TODO: types to convert from units and ratios
-	NanoCPUs         string            `yaml:"cpus,omitempty" json:"cpus,omitempty"`
+	// NanoCPUs is the quantity of CPUs. It used to be set as a string, which NanoCPUs.String() formats the same
+	// way: a decimal number of CPUs
+	NanoCPUs         NanoCPUs          `yaml:"cpus,omitempty" json:"cpus,omitempty"`
 	MemoryBytes      UnitBytes         `yaml:"memory,omitempty" json:"memory,omitempty"`
 	Pids             int64             `yaml:"pids,omitempty" json:"pids,omitempty"`
 	Devices          []DeviceRequest   `yaml:"devices,omitempty" json:"devices,omitempty"`
@@ -644,6 +648,9 @@ type NetworkConfig struct {
 	Labels     Labels     `yaml:"labels,omitempty" json:"labels,omitempty"`
 	EnableIPv6 bool       `yaml:"enable_ipv6,omitempty" json:"enable_ipv6,omitempty"`
 	Extensions Extensions `yaml:"#extensions,inline" json:"-"`
+
+	// Origin is the path of the compose file which declared this network, including files imported by `include`
+	Origin string `yaml:"-" json:"-"`
 }
 
 // IPAMConfig for a network
@@ -670,6 +677,9 @@ type VolumeConfig struct {
 	External   External   `yaml:"external,omitempty" json:"external,omitempty"`
 	Labels     Labels     `yaml:"labels,omitempty" json:"labels,omitempty"`
 	Extensions Extensions `yaml:"#extensions,inline" json:"-"`
+
+	// Origin is the path of the compose file which declared this volume, including files imported by `include`
+	Origin string `yaml:"-" json:"-"`
 }
 
 // External identifies a Volume or Network as a reference to a resource that is
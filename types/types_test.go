@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -241,6 +242,31 @@ func TestExtension(t *testing.T) {
 	assert.Check(t, ok == false)
 }
 
+type timeouts struct {
+	Connect Duration `yaml:"connect"`
+	Retries int      `yaml:"retries"`
+}
+
+func TestExtensionDecodeHooks(t *testing.T) {
+	x := Extensions{
+		"x-timeouts": map[string]interface{}{
+			"connect": "1m30s",
+			"retries": "3",
+		},
+		"x-decoded": timeouts{Retries: 2},
+	}
+	var actual timeouts
+	ok, err := x.Get("x-timeouts", &actual)
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+	assert.DeepEqual(t, actual, timeouts{Connect: Duration(90 * time.Second), Retries: 3})
+
+	ok, err = x.Get("x-decoded", &actual)
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+	assert.DeepEqual(t, actual, timeouts{Retries: 2})
+}
+
 func TestNewMapping(t *testing.T) {
 	m := NewMapping([]string{
 		"FOO=BAR",
@@ -0,0 +1,281 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package typeschema checks the Go model declared by the types package is consistent with the Compose
+// Specification JSON schema, and generates the JSON schema for the Go model.
+package typeschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/schema"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// Mismatch is an attribute only declared by either the JSON schema or the Go model
+type Mismatch struct {
+	// Path is the attribute pattern, using `*` for mapping keys and `[]` for list items
+	Path tree.Path
+	// InSchema is true when attribute is declared by JSON schema but not mapped by Go model, false for the reverse
+	InSchema bool
+}
+
+func (m Mismatch) String() string {
+	if m.InSchema {
+		return fmt.Sprintf("%s: declared by schema, not mapped by Go model", m.Path)
+	}
+	return fmt.Sprintf("%s: mapped by Go model, not declared by schema", m.Path)
+}
+
+// KnownDrift lists the known mismatches between the Go model and the embedded Compose Specification JSON schema,
+// sorted by path. Update when fixing one, or when introducing a new attribute on one side only on purpose.
+var KnownDrift = []Mismatch{
+	{Path: "configs.*.driver"},
+	{Path: "configs.*.driver_opts"},
+	{Path: "include", InSchema: true},
+	{Path: "networks.*.ipam.options", InSchema: true},
+	{Path: "secrets.*.content"},
+	{Path: "services.*.build.shm_size", InSchema: true},
+	{Path: "services.*.build.ulimits.*.single"},
+	{Path: "services.*.deploy.resources.limits.devices"},
+	{Path: "services.*.deploy.resources.limits.generic_resources"},
+	{Path: "services.*.deploy.resources.reservations.devices.[].options", InSchema: true},
+	{Path: "services.*.deploy.resources.reservations.pids"},
+	{Path: "services.*.dockerfile"},
+	{Path: "services.*.log_driver"},
+	{Path: "services.*.log_opt"},
+	{Path: "services.*.name"},
+	{Path: "services.*.net"},
+	{Path: "services.*.storage_opt", InSchema: true},
+	{Path: "services.*.ulimits.*.single"},
+	{Path: "services.*.volume_driver"},
+	{Path: "version", InSchema: true},
+}
+
+// WithoutKnownDrift returns mismatches not listed by KnownDrift
+func WithoutKnownDrift(mismatches []Mismatch) []Mismatch {
+	var unexpected []Mismatch
+	for _, m := range mismatches {
+		known := false
+		for _, k := range KnownDrift {
+			if m == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			unexpected = append(unexpected, m)
+		}
+	}
+	return unexpected
+}
+
+// Check compares types.Project, by yaml attribute names, with the embedded Compose Specification JSON schema, and
+// returns mismatches sorted by path
+func Check() ([]Mismatch, error) {
+	return CheckSchema(schema.Schema)
+}
+
+// CheckSchema compares types.Project, by yaml attribute names, with a Compose Specification JSON schema, and
+// returns mismatches sorted by path
+func CheckSchema(jsonSchema string) ([]Mismatch, error) {
+	var root map[string]any
+	if err := json.Unmarshal([]byte(jsonSchema), &root); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	c := checker{root: root}
+	c.compare("", root, reflect.TypeOf(types.Project{}))
+	sort.Slice(c.mismatches, func(i, j int) bool {
+		return c.mismatches[i].Path < c.mismatches[j].Path
+	})
+	return c.mismatches, nil
+}
+
+type checker struct {
+	root       map[string]any
+	mismatches []Mismatch
+	// visiting prevents infinite recursion on recursive definitions
+	visiting []reflect.Type
+}
+
+// compare walks schema node and Go type t in parallel
+func (c *checker) compare(path tree.Path, node map[string]any, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for _, v := range c.visiting {
+		if v == t {
+			return
+		}
+	}
+	c.visiting = append(c.visiting, t)
+	defer func() {
+		c.visiting = c.visiting[:len(c.visiting)-1]
+	}()
+
+	object := c.object(node)
+	switch t.Kind() {
+	case reflect.Struct:
+		properties, ok := object["properties"].(map[string]any)
+		if !ok {
+			// schema doesn't declare attributes
+			return
+		}
+		fields := yamlFields(t)
+		for name, property := range properties {
+			field, ok := fields[name]
+			if !ok {
+				c.mismatches = append(c.mismatches, Mismatch{Path: path.Next(name), InSchema: true})
+				continue
+			}
+			if p, ok := property.(map[string]any); ok {
+				c.compare(path.Next(name), p, field)
+			}
+		}
+		for name := range fields {
+			if _, ok := properties[name]; !ok {
+				c.mismatches = append(c.mismatches, Mismatch{Path: path.Next(name)})
+			}
+		}
+	case reflect.Map:
+		if element := elementOf(object); element != nil {
+			c.compare(path.Next(tree.PathMatchAll), element, t.Elem())
+		}
+	case reflect.Slice:
+		if items, ok := c.list(node)["items"].(map[string]any); ok {
+			c.compare(path.Next(tree.PathMatchList), items, t.Elem())
+		}
+	}
+}
+
+// resolve follows $ref to schema definitions
+func (c *checker) resolve(node map[string]any) map[string]any {
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node
+		}
+		target := any(c.root)
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			m, _ := target.(map[string]any)
+			target = m[part]
+		}
+		resolved, ok := target.(map[string]any)
+		if !ok {
+			return map[string]any{}
+		}
+		node = resolved
+	}
+}
+
+// alternatives returns node and the nodes it is composed of by oneOf, anyOf or allOf
+func (c *checker) alternatives(node map[string]any) []map[string]any {
+	node = c.resolve(node)
+	nodes := []map[string]any{node}
+	for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+		list, _ := node[keyword].([]any)
+		for _, e := range list {
+			if m, ok := e.(map[string]any); ok {
+				nodes = append(nodes, c.alternatives(m)...)
+			}
+		}
+	}
+	return nodes
+}
+
+// object merges the alternatives of node describing a mapping
+func (c *checker) object(node map[string]any) map[string]any {
+	object := map[string]any{}
+	properties := map[string]any{}
+	for _, n := range c.alternatives(node) {
+		if p, ok := n["properties"].(map[string]any); ok {
+			for k, v := range p {
+				properties[k] = v
+			}
+		}
+		for _, keyword := range []string{"patternProperties", "additionalProperties"} {
+			if v, ok := n[keyword]; ok {
+				if _, set := object[keyword].(map[string]any); !set {
+					object[keyword] = v
+				}
+			}
+		}
+	}
+	if len(properties) > 0 {
+		object["properties"] = properties
+	}
+	return object
+}
+
+// list returns the alternative of node describing a list
+func (c *checker) list(node map[string]any) map[string]any {
+	for _, n := range c.alternatives(node) {
+		if _, ok := n["items"].(map[string]any); ok {
+			return n
+		}
+	}
+	return map[string]any{}
+}
+
+// elementOf returns the schema for values of a mapping with user-defined keys
+func elementOf(object map[string]any) map[string]any {
+	patterns, _ := object["patternProperties"].(map[string]any)
+	for pattern, element := range patterns {
+		if strings.HasPrefix(pattern, "^x-") {
+			continue
+		}
+		if m, ok := element.(map[string]any); ok {
+			return m
+		}
+	}
+	if m, ok := object["additionalProperties"].(map[string]any); ok {
+		return m
+	}
+	return nil
+}
+
+// yamlFields returns struct fields types, by yaml attribute name. Extensions are ignored.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			if field.Type.Kind() == reflect.Struct {
+				for k, v := range yamlFields(field.Type) {
+					fields[k] = v
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
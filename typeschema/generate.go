@@ -0,0 +1,155 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package typeschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// custom are the JSON schemas for types implementing json.Marshaler
+var custom = map[reflect.Type]map[string]any{
+	reflect.TypeOf(types.UnitBytes(0)): {"type": "string"},
+	reflect.TypeOf(types.NanoCPUs(0)):  {"type": "string"},
+	reflect.TypeOf(types.Duration(0)):  {"type": "string", "format": "duration"},
+	reflect.TypeOf(types.HostsList{}):  {"type": "array", "items": map[string]any{"type": "string"}},
+	reflect.TypeOf(types.SSHKey{}):     {"type": "string"},
+}
+
+// Generate returns the JSON schema for types.Project, as serialized by MarshalJSON
+func Generate() ([]byte, error) {
+	g := generator{definitions: map[string]any{}}
+	root := g.schema(reflect.TypeOf(types.Project{}))
+	root["$schema"] = "http://json-schema.org/draft-07/schema#"
+	root["definitions"] = g.definitions
+	return json.MarshalIndent(root, "", "  ")
+}
+
+type generator struct {
+	definitions map[string]any
+}
+
+// schema returns the JSON schema for values of type t
+func (g *generator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if s, ok := custom[t]; ok {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == reflect.TypeOf(types.UlimitsConfig{}) {
+			// UlimitsConfig is serialized as a single integer when Single is set
+			return map[string]any{"oneOf": []any{
+				map[string]any{"type": "integer"},
+				g.object(t),
+			}}
+		}
+		if t == reflect.TypeOf(types.Project{}) {
+			// Project.MarshalJSON always sets name and services
+			project := g.object(t)
+			project["required"] = []string{"name", "services"}
+			return project
+		}
+		name := t.Name()
+		if _, ok := g.definitions[name]; !ok {
+			g.definitions[name] = map[string]any{} // placeholder for recursive types
+			g.definitions[name] = g.object(t)
+		}
+		return map[string]any{"$ref": "#/definitions/" + name}
+	case reflect.Map:
+		value := g.schema(t.Elem())
+		if t.Elem().Kind() == reflect.Pointer {
+			// nil values are serialized as null
+			value = map[string]any{"oneOf": []any{value, map[string]any{"type": "null"}}}
+		}
+		return map[string]any{"type": "object", "additionalProperties": value}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// object returns the JSON schema for struct t, by json attribute name. Attributes without omitempty are always
+// serialized, and as such required.
+func (g *generator) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	extensions := false
+	g.fields(t, properties, &required, &extensions)
+	object := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	if extensions {
+		object["patternProperties"] = map[string]any{"^x-": map[string]any{}}
+	}
+	return object
+}
+
+func (g *generator) fields(t reflect.Type, properties map[string]any, required *[]string, extensions *bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Type == reflect.TypeOf(types.Extensions{}) {
+			// extensions are serialized inline
+			*extensions = true
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.fields(field.Type, properties, required, extensions)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := g.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+			switch field.Type.Kind() {
+			case reflect.Slice, reflect.Map, reflect.Pointer:
+				// nil values are serialized as null
+				property = map[string]any{"oneOf": []any{property, map[string]any{"type": "null"}}}
+			}
+		}
+		properties[name] = property
+	}
+}
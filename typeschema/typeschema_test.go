@@ -0,0 +1,122 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package typeschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/diagnostics"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/xeipuuv/gojsonschema"
+	"gotest.tools/v3/assert"
+)
+
+func TestCheck(t *testing.T) {
+	mismatches, err := Check()
+	assert.NilError(t, err)
+	// known drift must be updated when fixing a mismatch
+	assert.DeepEqual(t, mismatches, KnownDrift)
+	assert.Equal(t, len(WithoutKnownDrift(mismatches)), 0)
+}
+
+func TestWithoutKnownDrift(t *testing.T) {
+	mismatches := []Mismatch{
+		{Path: "services.*.dockerfile"},
+		{Path: "services.*.helicopter", InSchema: true},
+		{Path: "version"},
+	}
+	assert.DeepEqual(t, WithoutKnownDrift(mismatches), []Mismatch{
+		{Path: "services.*.helicopter", InSchema: true},
+		{Path: "version"},
+	})
+}
+
+func TestCheckSchema(t *testing.T) {
+	mismatches, err := CheckSchema(`{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string"},
+    "services": {
+      "type": "object",
+      "patternProperties": {
+        "^[a-zA-Z0-9._-]+$": {"$ref": "#/definitions/service"}
+      }
+    }
+  },
+  "definitions": {
+    "service": {
+      "type": "object",
+      "properties": {
+        "image": {"type": "string"},
+        "helicopter": {"type": "string"}
+      }
+    }
+  }
+}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, mismatches[:3], []Mismatch{
+		{Path: "configs"},
+		{Path: "networks"},
+		{Path: "secrets"},
+	})
+	assert.Check(t, contains(mismatches, Mismatch{Path: "services.*.helicopter", InSchema: true}))
+	assert.Check(t, contains(mismatches, Mismatch{Path: "services.*.command"}))
+	assert.Check(t, !contains(mismatches, Mismatch{Path: "services.*.image"}))
+}
+
+func contains(mismatches []Mismatch, m Mismatch) bool {
+	for _, e := range mismatches {
+		if e == m {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGenerate(t *testing.T) {
+	generated, err := Generate()
+	assert.NilError(t, err)
+
+	homeDir, err := os.UserHomeDir()
+	assert.NilError(t, err)
+	workingDir, err := filepath.Abs("../loader")
+	assert.NilError(t, err)
+	project, err := loader.Load(types.ConfigDetails{
+		WorkingDir:  workingDir,
+		ConfigFiles: []types.ConfigFile{{Filename: filepath.Join(workingDir, "full-example.yml")}},
+		Environment: map[string]string{
+			"HOME": homeDir,
+			"BAR":  "this is a secret",
+			"QUX":  "qux_from_environment",
+		},
+	}, func(options *loader.Options) {
+		options.SetProjectName("full_example_project_name", true)
+		// full example declares conflicting attributes to cover all the model
+		options.SkipConsistencyCheck = true
+		options.Diagnostics = diagnostics.Discard
+	})
+	assert.NilError(t, err)
+	b, err := project.MarshalJSON()
+	assert.NilError(t, err)
+
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(generated), gojsonschema.NewBytesLoader(b))
+	assert.NilError(t, err)
+	assert.Check(t, result.Valid(), "%v", result.Errors())
+}
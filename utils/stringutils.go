@@ -18,6 +18,7 @@ package utils
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -38,6 +39,15 @@ func StringToBool(s string) bool {
 	return b
 }
 
+// base60 matches values a YAML 1.1 parser would read as a sexagesimal number, like `20:30`
+var base60 = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])+(?:\.[0-9_]*)?$`)
+
+// IsBase60 checks if a plain string would be read by a YAML 1.1 parser as a sexagesimal number, and as such
+// must be quoted
+func IsBase60(s string) bool {
+	return base60.MatchString(s)
+}
+
 // GetAsEqualsMap split key=value formatted strings into a key : value map
 func GetAsEqualsMap(em []string) map[string]string {
 	m := make(map[string]string)